@@ -0,0 +1,72 @@
+package app
+
+import (
+	"github.com/jafarsirojov/bank-front/pkg/core/analytics"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/history"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+)
+
+const topCounterparties = 10
+
+func (s *Server) handleAnalytics() http.HandlerFunc {
+	tpl, err := template.New("analytics.gohtml").Funcs(template.FuncMap{
+		"flowChart":    analytics.FlowChart,
+		"balanceChart": analytics.BalanceChart,
+	}).ParseFiles(filepath.Join("web/templates", "analytics.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		allCards, err := s.cardsSvc.AllCards(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		form := request.URL.Query()
+		location := userLocation(request)
+		filter, err := historyFilter(form, allCards, location)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		operations, err := s.historySvc.AllHistory(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get history: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		matched := make([]history.ModelOperationsLog, 0, len(operations))
+		for _, operation := range operations {
+			if filter.Match(operation) {
+				matched = append(matched, operation)
+			}
+		}
+		history.SetCurrencies(matched, cardCurrencies(allCards))
+
+		err = tpl.Execute(writer, struct {
+			analytics.Report
+			Cards []cards.Cards
+			Form  url.Values
+		}{
+			Report: analytics.Build(matched, location, topCounterparties),
+			Cards:  allCards,
+			Form:   form,
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
@@ -3,17 +3,33 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/attachments"
+	"github.com/jafarsirojov/bank-front/pkg/core/audit"
 	"github.com/jafarsirojov/bank-front/pkg/core/auth"
+	"github.com/jafarsirojov/bank-front/pkg/core/beneficiaries"
 	"github.com/jafarsirojov/bank-front/pkg/core/cards"
 	"github.com/jafarsirojov/bank-front/pkg/core/chat"
+	"github.com/jafarsirojov/bank-front/pkg/core/confirm"
+	"github.com/jafarsirojov/bank-front/pkg/core/devices"
+	"github.com/jafarsirojov/bank-front/pkg/core/fx"
 	"github.com/jafarsirojov/bank-front/pkg/core/history"
+	"github.com/jafarsirojov/bank-front/pkg/core/limits"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/notifications"
+	"github.com/jafarsirojov/bank-front/pkg/core/paymentrequests"
+	"github.com/jafarsirojov/bank-front/pkg/core/payments"
+	"github.com/jafarsirojov/bank-front/pkg/core/schedules"
 	"github.com/jafarsirojov/bank-front/pkg/core/utils"
 	"github.com/jafarsirojov/bank-front/pkg/jwt"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
 	"github.com/jafarsirojov/bank-front/pkg/mux"
 	"html/template"
-	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -24,10 +40,23 @@ type Server struct {
 	cardsSvc   *cards.Card
 	historySvc *history.History
 	chatSvc    *chat.Chat
+	quoter     *fx.Quoter
+	confirmer  *confirm.Confirmer
+
+	beneficiariesSvc *beneficiaries.Store
+	schedulesSvc     *schedules.Store
+	paymentsSvc      *payments.Payments
+	receiptsSvc      *payments.Receipts
+	limitsSvc        *limits.Store
+	auditLog         *audit.Log
+	requestsSvc      *paymentrequests.Store
+	attachmentsSvc   *attachments.Store
+	notifier         *notifications.Center
+	devicesSvc       *devices.Store
 }
 
-func NewServer(router *mux.ExactMux, secret jwt.Secret, authSvc *auth.Client, cardsSvc *cards.Card, historySvc *history.History, chatSvc *chat.Chat) *Server {
-	return &Server{router: router, secret: secret, authSvc: authSvc, cardsSvc: cardsSvc, historySvc: historySvc, chatSvc: chatSvc}
+func NewServer(router *mux.ExactMux, secret jwt.Secret, authSvc *auth.Client, cardsSvc *cards.Card, historySvc *history.History, chatSvc *chat.Chat, quoter *fx.Quoter, confirmer *confirm.Confirmer, beneficiariesSvc *beneficiaries.Store, schedulesSvc *schedules.Store, paymentsSvc *payments.Payments, receiptsSvc *payments.Receipts, limitsSvc *limits.Store, auditLog *audit.Log, requestsSvc *paymentrequests.Store, attachmentsSvc *attachments.Store, notifier *notifications.Center, devicesSvc *devices.Store) *Server {
+	return &Server{router: router, secret: secret, authSvc: authSvc, cardsSvc: cardsSvc, historySvc: historySvc, chatSvc: chatSvc, quoter: quoter, confirmer: confirmer, beneficiariesSvc: beneficiariesSvc, schedulesSvc: schedulesSvc, paymentsSvc: paymentsSvc, receiptsSvc: receiptsSvc, limitsSvc: limitsSvc, auditLog: auditLog, requestsSvc: requestsSvc, attachmentsSvc: attachmentsSvc, notifier: notifier, devicesSvc: devicesSvc}
 }
 
 func (s *Server) Start() {
@@ -62,7 +91,7 @@ func (s *Server) handleFrontPage() http.HandlerFunc {
 		// TODO: fetch data from multiple upstream services
 		err := tpl.Execute(writer, struct{}{})
 		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 		}
 	}
 }
@@ -93,7 +122,7 @@ func (s *Server) handleLoginPage() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		err := tpl.Execute(writer, struct{}{})
 		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 		}
 	}
 }
@@ -112,21 +141,21 @@ func (s *Server) handleLogin() http.HandlerFunc {
 		err := request.ParseForm()
 		if err != nil {
 			// TODO: show error page
-			log.Printf("error while parse login form: %v", err)
+			logging.Errorf(request.Context(), "error while parse login form: %v", err)
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
 		login := request.PostFormValue("login")
 		if login == "" {
 			// TODO: show error page
-			log.Print("login can't be empty")
+			logging.Warn(request.Context(), "login can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
 		password := request.PostFormValue("password")
 		if password == "" {
 			// TODO: show error page
-			log.Print("password can't be empty")
+			logging.Warn(request.Context(), "password can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
@@ -136,12 +165,10 @@ func (s *Server) handleLogin() http.HandlerFunc {
 			switch {
 			case errors.Is(err, context.DeadlineExceeded):
 				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
+				logging.Errorf(request.Context(), "service didn't respond in time: %v", err)
 			case errors.Is(err, context.Canceled):
 				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
+				logging.Warnf(request.Context(), "request is cancelled: %v", err)
 			case errors.Is(err, auth.ErrResponse):
 				var typedErr *auth.ErrorResponse
 				ok := errors.As(err, &typedErr)
@@ -158,7 +185,7 @@ func (s *Server) handleLogin() http.HandlerFunc {
 
 					err := tpl.Execute(writer, tplData)
 					if err != nil {
-						log.Print(err)
+						logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 					}
 				}
 			}
@@ -172,209 +199,14 @@ func (s *Server) handleLogin() http.HandlerFunc {
 			HttpOnly: true,
 		}
 		http.SetCookie(writer, cookie)
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
-	}
-}
-
-func (s *Server) handleChatPage() http.HandlerFunc {
-	var (
-		tpl *template.Template
-		err error
-	)
-	tpl, err = template.ParseFiles(filepath.Join("web/templates", "chat.gohtml"))
-	if err != nil {
-		panic(err)
-	}
-
-	return func(writer http.ResponseWriter, request *http.Request) {
-		err := tpl.Execute(writer, struct{}{})
-		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
-		}
-		http.Redirect(writer, request, Chat, http.StatusTemporaryRedirect)
-	}
-}
-
-func (s *Server) handleChat() http.HandlerFunc {
-	var (
-		tpl *template.Template
-		err error
-	)
-	tpl, err = template.ParseFiles(filepath.Join("web/templates", "history.gohtml"))
-	if err != nil {
-		panic(err)
-	}
-
-	return func(writer http.ResponseWriter, request *http.Request) {
-		log.Print("start handle profile  2")
-		ctx, _ := context.WithTimeout(context.Background(), 210*time.Second)
-		token, err := request.Cookie("token")
-		if err != nil {
-			log.Printf("can't token is nil: %d", err)
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-		///*authentication*/_, ok := jwt2.FromContext(request.Context()).(*Auth)
-		//if !ok {
-		//	log.Print("can't authentication is not ok")
-		//	http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-		//	return
-		//}
-		//authentication.Id==0
-		AllMessage, err := s.chatSvc.GetAllMessage(ctx, token.Value)
-
-		log.Print("start handle profile  3")
-
-		if err != nil {
-			log.Printf("error------------------------------------------ : %s", err)
-			switch {
-			case errors.Is(err, context.DeadlineExceeded):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
-				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-			case errors.Is(err, context.Canceled):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
-				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-			case errors.Is(err, auth.ErrResponse):
-				var typedErr *auth.ErrorResponse
-				ok := errors.As(err, &typedErr)
-				if ok {
-					tplData := struct {
-						Err string
-					}{
-						Err: "",
-					}
-					if utils.StringInSlice("err.password_mismatch", typedErr.Errors) {
-						tplData.Err = "err.password_mismatch"
-					}
-
-					err := tpl.Execute(writer, tplData)
-					if err != nil {
-						log.Print(err)
-					}
-				}
-			}
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-
-		log.Print("start handle profile  2")
-
-		tplData2 := struct {
-			Data []chat.ModelMassage
-		}{
-			Data: AllMessage,
-		}
-		err = tpl.Execute(writer, tplData2)
-		log.Print("start handle profile  2")
-		if err != nil {
-			log.Printf("can't execute: %d", err)
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-		http.Redirect(writer, request, Chat, http.StatusTemporaryRedirect)
-	}
-}
-
-func (s *Server) handleMessagePage() http.HandlerFunc {
-	var (
-		tpl *template.Template
-		err error
-	)
-	tpl, err = template.ParseFiles(filepath.Join("web/templates", "chat.gohtml"))
-	if err != nil {
-		panic(err)
-	}
-
-	return func(writer http.ResponseWriter, request *http.Request) {
-		err := tpl.Execute(writer, struct{}{})
-		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
-		}
-		http.Redirect(writer, request, Chat, http.StatusTemporaryRedirect)
-	}
-}
-
-func (s *Server) handleMessage() http.HandlerFunc {
-	var (
-		tpl *template.Template
-		err error
-	)
-	tpl, err = template.ParseFiles(filepath.Join("web/templates", "chat.gohtml"))
-	if err != nil {
-		panic(err)
-	}
-
-	return func(writer http.ResponseWriter, request *http.Request) {
-		log.Print("start handle profile  2")
-		ctx, _ := context.WithTimeout(context.Background(), 210*time.Second)
-		token, err := request.Cookie("token")
-		if err != nil {
-			log.Printf("can't token is nil: %d", err)
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-		///*authentication*/_, ok := jwt2.FromContext(request.Context()).(*Auth)
-		//if !ok {
-		//	log.Print("can't authentication is not ok")
-		//	http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-		//	return
-		//}
-		//authentication.Id==0
-		AllMessage, err := s.chatSvc.GetAllMessage(ctx, token.Value)
-
-		log.Print("start handle profile  3")
-
-		if err != nil {
-			log.Printf("error------------------------------------------ : %s", err)
-			switch {
-			case errors.Is(err, context.DeadlineExceeded):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
-				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-			case errors.Is(err, context.Canceled):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
-				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-			case errors.Is(err, auth.ErrResponse):
-				var typedErr *auth.ErrorResponse
-				ok := errors.As(err, &typedErr)
-				if ok {
-					tplData := struct {
-						Err string
-					}{
-						Err: "",
-					}
-					if utils.StringInSlice("err.password_mismatch", typedErr.Errors) {
-						tplData.Err = "err.password_mismatch"
-					}
-
-					err := tpl.Execute(writer, tplData)
-					if err != nil {
-						log.Print(err)
-					}
-				}
-			}
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-
-		log.Print("start handle profile  2")
-
-		tplData2 := struct {
-			Data []chat.ModelMassage
-		}{
-			Data: AllMessage,
-		}
-		err = tpl.Execute(writer, tplData2)
-		log.Print("start handle profile  2")
+		var payload Payload
+		err = jwt.Decode(token, &payload)
 		if err != nil {
-			log.Printf("can't execute: %d", err)
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
+			logging.Errorf(request.Context(), "can't decode token of login: %v", err)
+		} else {
+			s.checkDevice(writer, request, payload)
 		}
-		http.Redirect(writer, request, Chat, http.StatusTemporaryRedirect)
+		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
 	}
 }
 
@@ -385,19 +217,17 @@ type Auth struct {
 }
 
 func (s *Server) handleProfile() http.HandlerFunc {
-	log.Print("start handle profile")
 	var (
 		tpl *template.Template
 		err error
 	)
 	tpl, err = template.ParseFiles(filepath.Join("web/templates", "profile.html"))
 	if err != nil {
-		log.Printf("-----------------------------------%s", err)
 		panic(err)
 	}
 	return func(writer http.ResponseWriter, request *http.Request) {
-		log.Print("start handle profile  2")
-		ctx, _ := context.WithTimeout(context.Background(), 210*time.Second)
+		ctx, cancel := context.WithTimeout(request.Context(), 210*time.Second)
+		defer cancel()
 		token, err := request.Cookie("token")
 		if err != nil {
 			// FIXME
@@ -409,22 +239,17 @@ func (s *Server) handleProfile() http.HandlerFunc {
 			//FIXME
 			return
 		}
-		log.Println("payload:", payload)
 
 		allCards, err := s.cardsSvc.AllCards(ctx, token.Value)
 
-		log.Print("start handle profile  3")
-
 		if err != nil {
-			log.Printf("error------------------------------------------ : %s", err)
+			logging.Errorf(request.Context(), "can't get cards for profile page: %v", err)
 			switch {
 			case errors.Is(err, context.DeadlineExceeded):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
+				logging.Errorf(request.Context(), "service didn't respond in time: %v", err)
 				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			case errors.Is(err, context.Canceled):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
+				logging.Warnf(request.Context(), "request is cancelled: %v", err)
 				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			case errors.Is(err, auth.ErrResponse):
 				var typedErr *auth.ErrorResponse
@@ -441,7 +266,7 @@ func (s *Server) handleProfile() http.HandlerFunc {
 
 					err := tpl.Execute(writer, tplData)
 					if err != nil {
-						log.Print(err)
+						logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 					}
 				}
 			}
@@ -450,18 +275,14 @@ func (s *Server) handleProfile() http.HandlerFunc {
 
 		AllHistory, err := s.historySvc.AllHistory(ctx, token.Value)
 
-		log.Print("start handle profile  3")
-
 		if err != nil {
-			log.Printf("error------------------------------------------ : %s", err)
+			logging.Errorf(request.Context(), "can't get history for profile page: %v", err)
 			switch {
 			case errors.Is(err, context.DeadlineExceeded):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
+				logging.Errorf(request.Context(), "service didn't respond in time: %v", err)
 				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			case errors.Is(err, context.Canceled):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
+				logging.Warnf(request.Context(), "request is cancelled: %v", err)
 				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			case errors.Is(err, auth.ErrResponse):
 				var typedErr *auth.ErrorResponse
@@ -478,25 +299,25 @@ func (s *Server) handleProfile() http.HandlerFunc {
 
 					err := tpl.Execute(writer, tplData)
 					if err != nil {
-						log.Print(err)
+						logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 					}
 				}
 			}
 		}
 
-		log.Print("start handle profile  2")
 		err = tpl.Execute(writer, struct {
 			AllCards   []cards.Cards
 			AllHistory []history.ModelOperationsLog
 			IsAdmin    bool
+			Requests   []paymentrequests.Request
 		}{
 			AllCards:   allCards,
 			AllHistory: AllHistory,
 			IsAdmin:    payload.Id == 0,
+			Requests:   s.requestsSvc.Incoming(payload.Id, time.Now()),
 		})
-		log.Print("start handle profile  2")
 		if err != nil {
-			log.Printf("can't execute: %d", err)
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			return
 		}
@@ -505,151 +326,404 @@ func (s *Server) handleProfile() http.HandlerFunc {
 }
 
 func (s *Server) handleTransfer() http.HandlerFunc {
-	log.Print("start handle profile")
 	var (
 		tpl *template.Template
 		err error
 	)
-	tpl, err = template.ParseFiles(filepath.Join("web/templates", "transfer.gohtml"))
+	tpl, err = template.ParseFiles(filepath.Join("web/templates", "transferpreview.gohtml"))
 	if err != nil {
-		log.Printf("-----------------------------------%s", err)
 		panic(err)
 	}
 	return func(writer http.ResponseWriter, request *http.Request) {
 		err := request.ParseForm()
 		if err != nil {
 			// TODO: show error page
-			log.Printf("error while parse login form: %v", err)
+			logging.Errorf(request.Context(), "error while parse login form: %v", err)
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
-		numberCard := request.PostFormValue("numberCard")
-		if numberCard == "" {
-			// TODO: show error page
-			log.Print("numberCard can't be empty")
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			logging.Warn(request.Context(), "can't payload in context")
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			return
 		}
 		idCard := request.PostFormValue("idCard")
 		if idCard == "" {
 			// TODO: show error page
-			log.Print("idCard can't be empty")
+			logging.Warn(request.Context(), "idCard can't be empty")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		// transfer from chat conversation goes only to card of counterpart, payment message
+		// is posted to the thread, so number and beneficiary from form are ignored
+		chatID := request.PostFormValue("chat")
+		numberCard := request.PostFormValue("numberCard")
+		if chatID != "" {
+			numberCard, err = s.chatRecipientNumber(request.Context(), payload, idCard, chatID, token.Value)
+			if err != nil {
+				logging.Errorf(request.Context(), "can't resolve card of chat counterpart %s: %v", chatID, err)
+				if errors.Is(err, ErrNotOwner) {
+					http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+				http.Redirect(writer, request, ChatThread+chatID+"?err=chat.card", http.StatusSeeOther)
+				return
+			}
+		}
+		if numberCard == "" && chatID == "" {
+			numberCard = s.beneficiaryNumber(payload.Id, request.PostFormValue("beneficiary"))
+		}
+		if numberCard == "" {
+			// TODO: show error page
+			logging.Warn(request.Context(), "numberCard can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
 		count := request.PostFormValue("count")
 		if count == "" {
 			// TODO: show error page
-			log.Print("count can't be empty")
+			logging.Warn(request.Context(), "count can't be empty")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		sender, recipient, err := s.transferCards(request.Context(), payload, idCard, numberCard, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't resolve transfer cards: %v", err)
+			if errors.Is(err, ErrNotOwner) {
+				http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		amount, err := money.Parse(count, sender.Currency, money.DefaultLocale)
+		if err != nil || !amount.IsPositive() {
+			logging.Warnf(request.Context(), "invalid transfer amount %q: %v", count, err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		back := Transfer
+		data := map[string]string{}
+		if chatID != "" {
+			back = ChatThread + chatID
+			data["chat"] = chatID
+		}
+		preview, err := s.previewTransfer(request.Context(), payload, sender, recipient, amount, back, data, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't preview transfer: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		err = tpl.Execute(writer, preview)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+const (
+	actionTransfer = "transfer"
+	actionBlock    = "block"
+	actionUnblock  = "unblock"
+	actionView     = "view"
+	actionLimits   = "limits"
+	actionManual   = "manual_issue"
+)
+
+type transferPreview struct {
+	Sender          cards.Cards
+	RecipientName   string
+	RecipientNumber cards.Number
+	Amount          money.Money
+	Fee             money.Money
+	Debit           money.Money
+	Receive         money.Money
+	BalanceAfter    money.Money
+	Insufficient    bool
+	Limit           *limits.Exceeded
+	Quote           *fx.Quote
+	Token           string
+	TTLSeconds      int
+	Back            string
+}
+
+// previewTransfer quotes transfer, checks balance and limits and issues confirmation token
+// for handleTransferConfirm. Data is signed into token together with transfer parameters
+func (s *Server) previewTransfer(ctx context.Context, payload *Payload, sender cards.Cards, recipient cards.Cards, amount money.Money, back string, data map[string]string, token string) (transferPreview, error) {
+	preview := transferPreview{
+		Sender:          sender,
+		RecipientName:   maskName(recipient.Name),
+		RecipientNumber: recipient.Number,
+		Amount:          amount,
+		Fee:             money.New(0, amount.Currency),
+		Debit:           amount,
+		Receive:         amount,
+		Back:            back,
+	}
+	data["idCard"] = strconv.Itoa(sender.Id)
+	data["numberCard"] = string(recipient.Number)
+	data["amount"] = strconv.FormatInt(amount.Amount, 10)
+	data["currency"] = string(amount.Currency)
+
+	if recipient.Currency != sender.Currency {
+		parties := fx.Parties{OwnerID: payload.Id, SenderID: sender.Id, Recipient: string(recipient.Number)}
+		quote, err := s.quoter.Quote(ctx, parties, amount, recipient.Currency)
+		if err != nil {
+			return transferPreview{}, fmt.Errorf("can't quote transfer: %w", err)
+		}
+		preview.Quote = &quote
+		preview.Fee, preview.Debit, preview.Receive = quote.Fee, quote.Debit, quote.Receive
+		data["quote"] = quote.ID
+	}
+
+	var err error
+	preview.BalanceAfter, err = sender.Balance.Sub(preview.Debit)
+	if err != nil {
+		return transferPreview{}, fmt.Errorf("can't calculate balance after transfer: %w", err)
+	}
+	preview.Insufficient = preview.BalanceAfter.IsNegative()
+	err = s.checkLimits(ctx, sender, preview.Debit, false, token)
+	if err != nil && !errors.As(err, &preview.Limit) {
+		return transferPreview{}, fmt.Errorf("can't check limits: %w", err)
+	}
+
+	preview.Token, err = s.confirmer.Issue(payload.Id, actionTransfer, data)
+	if err != nil {
+		return transferPreview{}, fmt.Errorf("can't issue confirmation token: %w", err)
+	}
+	preview.TTLSeconds = int(s.confirmer.TTL().Seconds())
+	return preview, nil
+}
+
+// handleTransferConfirm executes transfer reviewed on preview page, token can be used only once
+func (s *Server) handleTransferConfirm() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "transferdone.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse confirm form: %v", err)
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
 
 		token, err := request.Cookie("token")
 		if err != nil {
-			log.Print("can't token in cookie")
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			logging.Warn(request.Context(), "can't payload in context")
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+
+		confirmation, err := s.confirmer.Redeem(request.PostFormValue("confirmation"), payload.Id, actionTransfer)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't redeem transfer confirmation: %v", err)
+			if errors.Is(err, confirm.ErrUsed) {
+				// double submit, first one is already processed
+				http.Redirect(writer, request, Profile, http.StatusSeeOther)
+				return
+			}
+			http.Redirect(writer, request, Transfer, http.StatusSeeOther)
+			return
+		}
+		var quote *fx.Quote
+		// while money surely wasn't moved confirmation and quote are given back, so form can be sent again
+		release := func() {
+			s.confirmer.Release(confirmation)
+			if quote != nil {
+				s.quoter.Return(*quote)
+			}
+		}
+
+		idCard := confirmation.Data["idCard"]
+		numberCard := cards.Number(confirmation.Data["numberCard"])
+		minor, err := strconv.ParseInt(confirmation.Data["amount"], 10, 64)
+		if err != nil {
+			logging.Warnf(request.Context(), "bad amount in confirmation: %v", err)
+			release()
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
+		amount := money.New(minor, money.Currency(confirmation.Data["currency"]))
+
+		if quoteID := confirmation.Data["quote"]; quoteID != "" {
+			senderID, _ := strconv.Atoi(idCard)
+			locked, err := s.quoter.Take(quoteID, fx.Parties{OwnerID: payload.Id, SenderID: senderID, Recipient: string(numberCard)})
+			if err != nil {
+				logging.Errorf(request.Context(), "can't take quote %s: %v", quoteID, err)
+				release()
+				http.Redirect(writer, request, Transfer, http.StatusSeeOther)
+				return
+			}
+			quote = &locked
+		}
 
-		err = s.cardsSvc.Transfer(request.Context(), numberCard, idCard, count, token.Value)
+		// limits and balance are checked again, other operations could be made after preview
+		sender, recipient, err := s.transferCards(request.Context(), payload, idCard, string(numberCard), token.Value)
 		if err != nil {
-			switch {
-			case errors.Is(err, context.DeadlineExceeded):
-				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
-			case errors.Is(err, context.Canceled):
-				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
-			case errors.Is(err, auth.ErrResponse):
-				var typedErr *auth.ErrorResponse
-				ok := errors.As(err, &typedErr)
-				if ok {
-					tplData := struct {
-						Err string
-					}{
-						Err: "",
-					}
-					// TODO: work with another
-					if utils.StringInSlice("err.password_mismatch", typedErr.Errors) {
-						tplData.Err = "err.password_mismatch"
-					}
+			logging.Errorf(request.Context(), "can't resolve transfer cards: %v", err)
+			release()
+			if errors.Is(err, ErrNotOwner) {
+				http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		debit := amount
+		if quote != nil {
+			debit = quote.Debit
+		}
+		balance, err := sender.Balance.Sub(debit)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't calculate balance after transfer: %v", err)
+			release()
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		if balance.IsNegative() {
+			logging.Warnf(request.Context(), "not enough money on card %d for %s", sender.Id, money.Format(debit, money.LocaleISO))
+			release()
+			http.Redirect(writer, request, CardPage+idCard+"?err=transfer.funds", http.StatusSeeOther)
+			return
+		}
+		err = s.checkLimits(request.Context(), sender, debit, false, token.Value)
+		if err != nil {
+			logging.Warnf(request.Context(), "transfer from card %d is not allowed: %v", sender.Id, err)
+			release()
+			var exceeded *limits.Exceeded
+			if errors.As(err, &exceeded) {
+				http.Redirect(writer, request, CardPage+idCard+"?err="+exceeded.Code(), http.StatusSeeOther)
+				return
+			}
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
 
-					err := tpl.Execute(writer, tplData)
-					if err != nil {
-						log.Print(err)
-					}
-				}
+		var paymentRequest *paymentrequests.Request
+		if requestID := confirmation.Data["request"]; requestID != "" {
+			locked, err := s.payRequest(payload, requestID)
+			if err != nil {
+				logging.Errorf(request.Context(), "can't pay request %s: %v", requestID, err)
+				release()
+				http.Redirect(writer, request, Requests, http.StatusSeeOther)
+				return
 			}
+			paymentRequest = &locked
+		}
+
+		err = s.cardsSvc.Transfer(request.Context(), numberCard, idCard, amount, quote, token.Value)
+		if errors.Is(err, cards.ErrResponse) {
+			// cards service rejected transfer, other errors are kept used: money could be moved already
+			release()
+		}
+		if paymentRequest != nil {
+			s.finishRequest(request.Context(), *paymentRequest, err)
+		}
+		if err == nil {
+			receive := amount
+			if quote != nil {
+				receive = quote.Receive
+			}
+			// owner is known only for transfer from chat or if cards service sends it
+			recipientID := recipient.OwnerID
+			if counterpartID, err := strconv.Atoi(confirmation.Data["chat"]); err == nil {
+				recipientID = counterpartID
+			}
+			if paymentRequest != nil {
+				recipientID = paymentRequest.RequesterID
+			}
+			s.notifyTransfer(request.Context(), payload.Id, sender, recipientID, recipient, amount, receive)
+		}
+		if paymentRequest != nil {
+			page := RequestPage + "?token=" + url.QueryEscape(paymentRequest.Token)
+			switch {
+			case errors.Is(err, cards.ErrResponse):
+				logging.Errorf(request.Context(), "can't transfer for request %d: %v", paymentRequest.Id, err)
+				page += "&err=request.transfer"
+			case err != nil:
+				logging.Errorf(request.Context(), "can't transfer for request %d: %v", paymentRequest.Id, err)
+				page += "&err=request.unknown"
+			}
+			http.Redirect(writer, request, page, http.StatusSeeOther)
+			return
+		}
+		if chatID := confirmation.Data["chat"]; chatID != "" {
+			s.postPayment(request.Context(), payload, chatID, amount, err, token.Value)
+			thread := ChatThread + chatID
+			if err != nil {
+				logging.Errorf(request.Context(), "can't transfer: %v", err)
+				thread += "?err=chat.payment"
+			}
+			http.Redirect(writer, request, thread, http.StatusSeeOther)
+			return
+		}
+		if err != nil {
+			logging.Errorf(request.Context(), "can't transfer: %v", err)
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
+
+		_, saved := s.beneficiariesSvc.FindByNumber(payload.Id, numberCard)
+		err = tpl.Execute(writer, struct {
+			Amount money.Money
+			Number cards.Number
+			Saved  bool
+		}{
+			Amount: amount,
+			Number: numberCard,
+			Saved:  saved,
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
 	}
 }
 
-func (s *Server) handlePayment() http.HandlerFunc {
-	log.Print("start handle profile")
-	var (
-		_   *template.Template //tpl *template.Template
-		err error
-	)
-	_, err = template.ParseFiles(filepath.Join("web/templates", "payment.gohtml")) //tpl
+// transferCards finds sender card among cards of user and recipient card by number
+func (s *Server) transferCards(ctx context.Context, payload *Payload, idCard string, numberCard string, token string) (sender cards.Cards, recipient cards.Cards, err error) {
+	number, err := cards.ParseNumber(numberCard)
 	if err != nil {
-		log.Printf("-----------------------------------%s", err)
-		panic(err)
+		return cards.Cards{}, cards.Cards{}, err
 	}
-	return func(writer http.ResponseWriter, request *http.Request) {
-		//	log.Print("start handle profile  2")
-		//	ctx, _ := context.WithTimeout(context.Background(), 2*time.Second)
-		//
-		//	//allCards, err := s.cardsSvc.AllCards(ctx)
-		//	log.Print("start handle profile  3")
-		//
-		//	if err != nil {
-		//		log.Printf("error------------------------------------------ : %s", err)
-		//		switch {
-		//		case errors.Is(err, context.DeadlineExceeded):
-		//			log.Print("auth service didn't response in given time")
-		//			log.Print("another err")
-		//			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-		//		case errors.Is(err, context.Canceled):
-		//			log.Print("auth service didn't response in given time")
-		//			log.Print("another err")
-		//			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-		//		case errors.Is(err, auth.ErrResponse):
-		//			var typedErr *auth.ErrorResponse
-		//			ok := errors.As(err, &typedErr)
-		//			if ok {
-		//				tplData := struct {
-		//					Err string
-		//				}{
-		//					Err: "",
-		//				}
-		//				if utils.StringInSlice("err.password_mismatch", typedErr.Errors) {
-		//					tplData.Err = "err.password_mismatch"
-		//				}
-		//
-		//				err := tpl.Execute(writer, tplData)
-		//				if err != nil {
-		//					log.Print(err)
-		//				}
-		//			}
-		//		}
-		//		return
-		//	}
-		//	log.Print("start handle profile  2")
-		//	err = tpl.Execute(writer, allCards)
-		//	log.Print("start handle profile  2")
-		//	if err != nil {
-		//		log.Printf("can't execute: %d", err)
-		//		http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
-		//		return
-		//	}
-		//	//http.Redirect(writer, request, Posts, http.StatusTemporaryRedirect)
+	sender, err = s.ownedCard(ctx, payload, idCard, actionTransfer, token)
+	if err != nil {
+		return cards.Cards{}, cards.Cards{}, err
+	}
+	recipient, err = s.cardsSvc.CardByNumber(ctx, number, token)
+	if err != nil {
+		return cards.Cards{}, cards.Cards{}, fmt.Errorf("can't get recipient card: %w", err)
+	}
+	return sender, recipient, nil
+}
+
+// maskName keeps first word and initials of others: "Jafar Sirojov" -> "Jafar S."
+func maskName(name string) string {
+	words := strings.Fields(name)
+	for i := 1; i < len(words); i++ {
+		initial := []rune(words[i])[0]
+		words[i] = string(initial) + "."
 	}
+	return strings.Join(words, " ")
 }
 
 func (s *Server) handleCardsPage() http.HandlerFunc {
@@ -665,40 +739,35 @@ func (s *Server) handleCardsPage() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		err := tpl.Execute(writer, struct{}{})
 		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 		}
 		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
 	}
 }
 
 func (s *Server) handleCards() http.HandlerFunc {
-	log.Print("start handle profile")
 	var (
 		tpl *template.Template
 		err error
 	)
 	tpl, err = template.ParseFiles(filepath.Join("web/templates", "profile.html"))
 	if err != nil {
-		log.Printf("-----------------------------------%s", err)
 		panic(err)
 	}
 	return func(writer http.ResponseWriter, request *http.Request) {
-		log.Print("start handle profile  2")
-		ctx, _ := context.WithTimeout(context.Background(), 2*time.Second)
+		ctx, cancel := context.WithTimeout(request.Context(), 2*time.Second)
+		defer cancel()
 		asd := ""
 		allCards, err := s.cardsSvc.AllCards(ctx, asd)
-		log.Print("start handle profile  3")
 
 		if err != nil {
-			log.Printf("error------------------------------------------ : %s", err)
+			logging.Errorf(request.Context(), "can't get cards for cards page: %v", err)
 			switch {
 			case errors.Is(err, context.DeadlineExceeded):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
+				logging.Errorf(request.Context(), "service didn't respond in time: %v", err)
 				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			case errors.Is(err, context.Canceled):
-				log.Print("auth service didn't response in given time")
-				log.Print("another err")
+				logging.Warnf(request.Context(), "request is cancelled: %v", err)
 				http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			case errors.Is(err, auth.ErrResponse):
 				var typedErr *auth.ErrorResponse
@@ -715,17 +784,15 @@ func (s *Server) handleCards() http.HandlerFunc {
 
 					err := tpl.Execute(writer, tplData)
 					if err != nil {
-						log.Print(err)
+						logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 					}
 				}
 			}
 			return
 		}
-		log.Print("start handle profile  2")
 		err = tpl.Execute(writer, allCards)
-		log.Print("start handle profile  2")
 		if err != nil {
-			log.Printf("can't execute: %d", err)
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 			return
 		}
@@ -744,11 +811,30 @@ func (s *Server) handleTransferPage() http.HandlerFunc {
 	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
-		err := tpl.Execute(writer, struct{}{})
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		allCards, err := s.userCards(request)
 		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		idCard, _ := strconv.Atoi(request.URL.Query().Get("idCard"))
+		err = tpl.Execute(writer, struct {
+			Beneficiaries []beneficiaries.Beneficiary
+			Cards         []cards.Cards
+			IdCard        int
+		}{
+			Beneficiaries: s.beneficiariesSvc.List(payload.Id),
+			Cards:         allCards,
+			IdCard:        idCard,
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 		}
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
 	}
 }
 
@@ -765,57 +851,54 @@ func (s *Server) handleRegisterPage() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		err := tpl.Execute(writer, struct{}{})
 		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 		}
-		log.Println("writer: ", writer, "tpl", tpl)
 		http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
 	}
 }
 
 func (s *Server) handleRegister() http.HandlerFunc {
-	log.Print("start handle profile")
 	var (
 		tpl *template.Template
 		err error
 	)
 	tpl, err = template.ParseFiles(filepath.Join("web/templates", "register.html"))
 	if err != nil {
-		log.Printf("-----------------------------------%s", err)
 		panic(err)
 	}
 	return func(writer http.ResponseWriter, request *http.Request) {
 		err := request.ParseForm()
 		if err != nil {
 			// TODO: show error page
-			log.Printf("error while parse login form: %v", err)
+			logging.Errorf(request.Context(), "error while parse login form: %v", err)
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
 		name := request.PostFormValue("name")
 		if name == "" {
 			// TODO: show error page
-			log.Print("numberCard can't be empty")
+			logging.Warn(request.Context(), "numberCard can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
 		login := request.PostFormValue("login")
 		if login == "" {
 			// TODO: show error page
-			log.Print("idCard can't be empty")
+			logging.Warn(request.Context(), "idCard can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
 		password := request.PostFormValue("password")
 		if password == "" {
 			// TODO: show error page
-			log.Print("count can't be empty")
+			logging.Warn(request.Context(), "count can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
 		phone := request.PostFormValue("phone")
 		if phone == "" {
 			// TODO: show error page
-			log.Print("count can't be empty")
+			logging.Warn(request.Context(), "count can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
@@ -825,12 +908,10 @@ func (s *Server) handleRegister() http.HandlerFunc {
 			switch {
 			case errors.Is(err, context.DeadlineExceeded):
 				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
+				logging.Errorf(request.Context(), "service didn't respond in time: %v", err)
 			case errors.Is(err, context.Canceled):
 				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
+				logging.Warnf(request.Context(), "request is cancelled: %v", err)
 			case errors.Is(err, auth.ErrResponse):
 				var typedErr *auth.ErrorResponse
 				ok := errors.As(err, &typedErr)
@@ -847,7 +928,7 @@ func (s *Server) handleRegister() http.HandlerFunc {
 
 					err := tpl.Execute(writer, tplData)
 					if err != nil {
-						log.Print(err)
+						logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 					}
 				}
 			}
@@ -858,106 +939,6 @@ func (s *Server) handleRegister() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAddCardPage() http.HandlerFunc {
-	var (
-		tpl *template.Template
-		err error
-	)
-	tpl, err = template.ParseFiles(filepath.Join("web/templates", "addcard.gohtml"))
-	if err != nil {
-		panic(err)
-	}
-
-	return func(writer http.ResponseWriter, request *http.Request) {
-		err := tpl.Execute(writer, struct{}{})
-		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
-		}
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
-	}
-}
-
-func (s *Server) handleAddCard() http.HandlerFunc {
-	log.Print("start handle profile")
-	var (
-		tpl *template.Template
-		err error
-	)
-	tpl, err = template.ParseFiles(filepath.Join("web/templates", "addcard.gohtml"))
-	if err != nil {
-		log.Printf("-----------------------------------%s", err)
-		panic(err)
-	}
-	return func(writer http.ResponseWriter, request *http.Request) {
-		err := request.ParseForm()
-		if err != nil {
-			// TODO: show error page
-			log.Printf("error while parse login form: %v", err)
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-		name := request.PostFormValue("name")
-		if name == "" {
-			// TODO: show error page
-			log.Print("name can't be empty")
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-		balance := request.PostFormValue("balance")
-		if balance == "" {
-			// TODO: show error page
-			log.Print("balance can't be empty")
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-		ownerid := request.PostFormValue("ownerid")
-		if ownerid == "" {
-			// TODO: show error page
-			log.Print("ownerid can't be empty")
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-		token, err := request.Cookie("token")
-
-		err = s.cardsSvc.AddCard(request.Context(), name, balance, ownerid, token.Value)
-		if err != nil {
-			log.Printf("can't add card %s", err)
-			switch {
-			case errors.Is(err, context.DeadlineExceeded):
-				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
-			case errors.Is(err, context.Canceled):
-				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
-			case errors.Is(err, auth.ErrResponse):
-				var typedErr *auth.ErrorResponse
-				ok := errors.As(err, &typedErr)
-				if ok {
-					tplData := struct {
-						Err string
-					}{
-						Err: "",
-					}
-					// TODO: work with another
-					if utils.StringInSlice("err.password_mismatch", typedErr.Errors) {
-						tplData.Err = "err.password_mismatch"
-					}
-
-					err := tpl.Execute(writer, tplData)
-					if err != nil {
-						log.Print(err)
-					}
-				}
-			}
-			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
-			return
-		}
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
-	}
-}
-
 func (s *Server) handlePageErrorClient() http.HandlerFunc {
 	var (
 		tpl *template.Template
@@ -971,7 +952,7 @@ func (s *Server) handlePageErrorClient() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		err := tpl.Execute(writer, struct{}{})
 		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 			return
 		}
 		//http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
@@ -989,30 +970,40 @@ func (s *Server) handleBlockPage() http.HandlerFunc {
 	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
-		err := tpl.Execute(writer, struct{}{})
+		allCards, err := s.userCards(request)
 		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		id, _ := strconv.Atoi(request.URL.Query().Get("id"))
+		err = tpl.Execute(writer, struct {
+			Cards []cards.Cards
+			Id    int
+		}{
+			Cards: allCards,
+			Id:    id,
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 		}
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
 	}
 }
 
 func (s *Server) handleBlock() http.HandlerFunc {
-	log.Print("start handle profile")
 	var (
 		tpl *template.Template
 		err error
 	)
 	tpl, err = template.ParseFiles(filepath.Join("web/templates", "block.gohtml"))
 	if err != nil {
-		log.Printf("-----------------------------------%s", err)
 		panic(err)
 	}
 	return func(writer http.ResponseWriter, request *http.Request) {
 		err := request.ParseForm()
 		if err != nil {
 			// TODO: show error page
-			log.Printf("error while parse login form: %v", err)
+			logging.Errorf(request.Context(), "error while parse login form: %v", err)
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
@@ -1026,7 +1017,7 @@ func (s *Server) handleBlock() http.HandlerFunc {
 		idCard := request.PostFormValue("id")
 		if idCard == "" {
 			// TODO: show error page
-			log.Print("idCard can't be empty")
+			logging.Warn(request.Context(), "idCard can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
@@ -1040,7 +1031,23 @@ func (s *Server) handleBlock() http.HandlerFunc {
 
 		token, err := request.Cookie("token")
 		if err != nil {
-			log.Print("can't token in cookie")
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		card, err := s.ownedCard(request.Context(), payload, idCard, actionBlock, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't use card %s: %v", idCard, err)
+			if errors.Is(err, ErrNotOwner) {
+				http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
@@ -1050,12 +1057,10 @@ func (s *Server) handleBlock() http.HandlerFunc {
 			switch {
 			case errors.Is(err, context.DeadlineExceeded):
 				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
+				logging.Errorf(request.Context(), "service didn't respond in time: %v", err)
 			case errors.Is(err, context.Canceled):
 				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
+				logging.Warnf(request.Context(), "request is cancelled: %v", err)
 			case errors.Is(err, auth.ErrResponse):
 				var typedErr *auth.ErrorResponse
 				ok := errors.As(err, &typedErr)
@@ -1072,14 +1077,15 @@ func (s *Server) handleBlock() http.HandlerFunc {
 
 					err := tpl.Execute(writer, tplData)
 					if err != nil {
-						log.Print(err)
+						logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 					}
 				}
 			}
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
+		s.notifyCard(request.Context(), payload, card, true)
+		http.Redirect(writer, request, CardPage+idCard, http.StatusSeeOther)
 	}
 }
 
@@ -1094,30 +1100,40 @@ func (s *Server) handleUnBlockPage() http.HandlerFunc {
 	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
-		err := tpl.Execute(writer, struct{}{})
+		allCards, err := s.userCards(request)
 		if err != nil {
-			log.Printf("error while executing template %s %v", tpl.Name(), err)
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		id, _ := strconv.Atoi(request.URL.Query().Get("id"))
+		err = tpl.Execute(writer, struct {
+			Cards []cards.Cards
+			Id    int
+		}{
+			Cards: allCards,
+			Id:    id,
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 		}
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
 	}
 }
 
 func (s *Server) handleUnBlock() http.HandlerFunc {
-	log.Print("start handle profile")
 	var (
 		tpl *template.Template
 		err error
 	)
 	tpl, err = template.ParseFiles(filepath.Join("web/templates", "block.gohtml"))
 	if err != nil {
-		log.Printf("-----------------------------------%s", err)
 		panic(err)
 	}
 	return func(writer http.ResponseWriter, request *http.Request) {
 		err := request.ParseForm()
 		if err != nil {
 			// TODO: show error page
-			log.Printf("error while parse login form: %v", err)
+			logging.Errorf(request.Context(), "error while parse login form: %v", err)
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
@@ -1131,7 +1147,7 @@ func (s *Server) handleUnBlock() http.HandlerFunc {
 		idCard := request.PostFormValue("id")
 		if idCard == "" {
 			// TODO: show error page
-			log.Print("idCard can't be empty")
+			logging.Warn(request.Context(), "idCard can't be empty")
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
@@ -1145,7 +1161,23 @@ func (s *Server) handleUnBlock() http.HandlerFunc {
 
 		token, err := request.Cookie("token")
 		if err != nil {
-			log.Print("can't token in cookie")
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		card, err := s.ownedCard(request.Context(), payload, idCard, actionUnblock, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't use card %s: %v", idCard, err)
+			if errors.Is(err, ErrNotOwner) {
+				http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
@@ -1155,12 +1187,10 @@ func (s *Server) handleUnBlock() http.HandlerFunc {
 			switch {
 			case errors.Is(err, context.DeadlineExceeded):
 				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
+				logging.Errorf(request.Context(), "service didn't respond in time: %v", err)
 			case errors.Is(err, context.Canceled):
 				// TODO: show error page (for deadline)
-				log.Print("auth service didn't response in given time")
-				log.Print("another err") // parse it
+				logging.Warnf(request.Context(), "request is cancelled: %v", err)
 			case errors.Is(err, auth.ErrResponse):
 				var typedErr *auth.ErrorResponse
 				ok := errors.As(err, &typedErr)
@@ -1177,13 +1207,14 @@ func (s *Server) handleUnBlock() http.HandlerFunc {
 
 					err := tpl.Execute(writer, tplData)
 					if err != nil {
-						log.Print(err)
+						logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
 					}
 				}
 			}
 			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
 			return
 		}
-		http.Redirect(writer, request, Profile, http.StatusTemporaryRedirect)
+		s.notifyCard(request.Context(), payload, card, false)
+		http.Redirect(writer, request, CardPage+idCard, http.StatusSeeOther)
 	}
 }
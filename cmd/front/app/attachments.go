@@ -0,0 +1,147 @@
+package app
+
+import (
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/attachments"
+	"github.com/jafarsirojov/bank-front/pkg/core/chat"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// form fields and multipart headers are small, everything above file limit is rejected
+const maxAttachmentBody = attachments.MaxSize + 64<<10
+
+func (s *Server) handleChatAttach() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		// recipient is in query, so user gets back to thread even if body is too large to parse
+		recipientID, err := strconv.Atoi(request.URL.Query().Get("to"))
+		if err != nil || recipientID < 0 || recipientID == payload.Id {
+			http.Redirect(writer, request, Chat+"?err=chat.recipient", http.StatusSeeOther)
+			return
+		}
+		thread := ChatThread + strconv.Itoa(recipientID)
+
+		request.Body = http.MaxBytesReader(writer, request.Body, maxAttachmentBody)
+		err = request.ParseMultipartForm(attachments.MaxSize)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't parse attachment form of %d: %v", payload.Id, err)
+			http.Redirect(writer, request, thread+"?err=chat.attachment.size", http.StatusSeeOther)
+			return
+		}
+		defer func() {
+			err := request.MultipartForm.RemoveAll()
+			if err != nil {
+				logging.Errorf(request.Context(), "can't remove temp files of attachment: %v", err)
+			}
+		}()
+
+		file, header, err := request.FormFile("file")
+		if err != nil {
+			http.Redirect(writer, request, thread+"?err=chat.attachment.empty", http.StatusSeeOther)
+			return
+		}
+		defer file.Close()
+		data, err := ioutil.ReadAll(io.LimitReader(file, attachments.MaxSize+1))
+		if err != nil {
+			logging.Errorf(request.Context(), "can't read attachment of %d: %v", payload.Id, err)
+			http.Redirect(writer, request, thread+"?err=chat.send", http.StatusSeeOther)
+			return
+		}
+
+		attachment, err := s.attachmentsSvc.Save(request.Context(), payload.Id, recipientID, header.Filename, data, time.Now())
+		if err != nil {
+			logging.Errorf(request.Context(), "can't save attachment from %d to %d: %v", payload.Id, recipientID, err)
+			http.Redirect(writer, request, thread+"?err="+attachmentErrCode(err), http.StatusSeeOther)
+			return
+		}
+		sent, err := s.chatSvc.SendMessage(request.Context(), chat.AttachmentMessage(payload.Id, recipientID, chat.Attachment{
+			ID:          attachment.ID,
+			Name:        attachment.Name,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+		}), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't send attachment %s from %d to %d: %v", attachment.ID, payload.Id, recipientID, err)
+			http.Redirect(writer, request, thread+"?err="+chatErrCode(err), http.StatusSeeOther)
+			return
+		}
+		s.notifyMessage(request.Context(), sent)
+		http.Redirect(writer, request, thread, http.StatusSeeOther)
+	}
+}
+
+// handleChatAttachment serves file from path, e.g. /chat/attachments/{id}.
+// Attachment of other conversation is not found, so ids can't be checked by guessing
+func (s *Server) handleChatAttachment() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		attachment, err := s.attachmentsSvc.Get(strings.TrimPrefix(request.URL.Path, ChatAttachment))
+		if err != nil || !attachment.VisibleTo(payload.Id) {
+			http.NotFound(writer, request)
+			return
+		}
+		content, err := s.attachmentsSvc.Open(request.Context(), attachment)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't open attachment %s: %v", attachment.ID, err)
+			http.NotFound(writer, request)
+			return
+		}
+		defer content.Close()
+
+		// type is sniffed on upload, browser must not guess another one;
+		// pdf is downloaded so scripts in it don't run with our origin
+		disposition := "attachment"
+		if attachment.IsImage() {
+			disposition = "inline"
+		}
+		header := writer.Header()
+		header.Set("Content-Type", attachment.ContentType)
+		header.Set("Content-Length", strconv.Itoa(attachment.Size))
+		if value := mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Name}); value != "" {
+			disposition = value
+		}
+		header.Set("Content-Disposition", disposition)
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Content-Security-Policy", "default-src 'none'; sandbox")
+		header.Set("Cache-Control", "private, max-age=86400")
+		_, err = io.Copy(writer, content)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't write attachment %s: %v", attachment.ID, err)
+		}
+	}
+}
+
+func attachmentErrCode(err error) string {
+	switch {
+	case errors.Is(err, attachments.ErrTooLarge):
+		return "chat.attachment.size"
+	case errors.Is(err, attachments.ErrType), errors.Is(err, attachments.ErrCorrupt):
+		return "chat.attachment.type"
+	case errors.Is(err, attachments.ErrEmpty):
+		return "chat.attachment.empty"
+	default:
+		return "chat.send"
+	}
+}
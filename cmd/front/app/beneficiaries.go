@@ -0,0 +1,141 @@
+package app
+
+import (
+	"github.com/jafarsirojov/bank-front/pkg/core/beneficiaries"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+)
+
+func (s *Server) handleBeneficiaries() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "beneficiaries.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := tpl.Execute(writer, struct {
+			Beneficiaries []beneficiaries.Beneficiary
+			Err           string
+		}{
+			Beneficiaries: s.beneficiariesSvc.List(payload.Id),
+			Err:           request.URL.Query().Get("err"),
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+// handleBeneficiaryAdd is used by address book page and by "save recipient" form after transfer
+func (s *Server) handleBeneficiaryAdd() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse beneficiary form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		number, err := cards.ParseNumber(request.PostFormValue("number"))
+		if err != nil {
+			logging.Errorf(request.Context(), "can't add beneficiary: %v", err)
+			http.Redirect(writer, request, Beneficiaries+"?err=number", http.StatusSeeOther)
+			return
+		}
+		_, err = s.beneficiariesSvc.Add(payload.Id, request.PostFormValue("nickname"), number)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't add beneficiary: %v", err)
+			http.Redirect(writer, request, Beneficiaries+"?err=nickname", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(writer, request, Beneficiaries, http.StatusSeeOther)
+	}
+}
+
+func (s *Server) handleBeneficiaryEdit() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse beneficiary form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		id, err := strconv.ParseInt(request.PostFormValue("id"), 10, 64)
+		if err != nil {
+			logging.Warnf(request.Context(), "bad beneficiary id: %v", err)
+			http.Redirect(writer, request, Beneficiaries, http.StatusSeeOther)
+			return
+		}
+
+		err = s.beneficiariesSvc.Rename(payload.Id, id, request.PostFormValue("nickname"))
+		if err != nil {
+			logging.Errorf(request.Context(), "can't rename beneficiary %d: %v", id, err)
+			http.Redirect(writer, request, Beneficiaries+"?err=nickname", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(writer, request, Beneficiaries, http.StatusSeeOther)
+	}
+}
+
+func (s *Server) handleBeneficiaryDelete() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse beneficiary form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		id, err := strconv.ParseInt(request.PostFormValue("id"), 10, 64)
+		if err != nil {
+			logging.Warnf(request.Context(), "bad beneficiary id: %v", err)
+			http.Redirect(writer, request, Beneficiaries, http.StatusSeeOther)
+			return
+		}
+
+		err = s.beneficiariesSvc.Delete(payload.Id, id)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't delete beneficiary %d: %v", id, err)
+		}
+		http.Redirect(writer, request, Beneficiaries, http.StatusSeeOther)
+	}
+}
+
+// beneficiaryNumber returns saved card number for id chosen in transfer form dropdown
+func (s *Server) beneficiaryNumber(ownerID int, idValue string) string {
+	if idValue == "" {
+		return ""
+	}
+	id, err := strconv.ParseInt(idValue, 10, 64)
+	if err != nil {
+		return ""
+	}
+	beneficiary, err := s.beneficiariesSvc.Get(ownerID, id)
+	if err != nil {
+		return ""
+	}
+	return string(beneficiary.Number)
+}
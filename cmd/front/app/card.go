@@ -0,0 +1,90 @@
+package app
+
+import (
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/history"
+	"github.com/jafarsirojov/bank-front/pkg/core/limits"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// miniStatementSize is how many last operations are shown on card page
+const miniStatementSize = 10
+
+func (s *Server) handleCard() http.HandlerFunc {
+	tpl, err := template.New("card.gohtml").Funcs(template.FuncMap{
+		"limitAmount": func(amount int64, currency money.Currency) string {
+			return money.FormatAmount(money.New(amount, currency), money.DefaultLocale)
+		},
+	}).ParseFiles(filepath.Join("web/templates", "card.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		card, err := s.ownedCard(request.Context(), payload, strings.TrimPrefix(request.URL.Path, CardPage), actionView, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't show card: %v", err)
+			http.NotFound(writer, request)
+			return
+		}
+		allCards, err := s.cardsSvc.AllCards(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		page, err := s.historySvc.Page(request.Context(), history.Query{
+			Filter: history.Filter{Number: card.Number},
+			Limit:  miniStatementSize,
+		}, token.Value)
+		if err != nil {
+			// card is still shown, history service may be down
+			logging.Errorf(request.Context(), "can't get history of card %d: %v", card.Id, err)
+		}
+		history.SetCurrencies(page.Operations, cardCurrencies(allCards))
+		spent, err := s.spent(request.Context(), card, time.Now(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't count spent of card %d: %v", card.Id, err)
+		}
+		cardLimits := s.limitsSvc.Get(card.Id)
+
+		err = tpl.Execute(writer, struct {
+			Card       cards.Cards
+			Operations []history.ModelOperationsLog
+			More       bool
+			Location   *time.Location
+			Limits     limits.Limits
+			Spent      limits.Spent
+			Err        string
+		}{
+			Card:       card,
+			Operations: page.Operations,
+			More:       page.Next != "",
+			Location:   userLocation(request),
+			Limits:     cardLimits,
+			Spent:      spent,
+			Err:        request.URL.Query().Get("err"),
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/chat"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type chatPage struct {
+	UserID        int
+	Conversations []chat.Conversation
+	Thread        *chat.Conversation
+	Cards         []cards.Cards
+	RecipientID   string
+	Text          string
+	Err           string
+	// LastID is id of newest message on page, event stream starts after it
+	LastID int
+}
+
+func (s *Server) handleChat() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "chat.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		s.renderChat(writer, request, tpl, chatPage{RecipientID: request.URL.Query().Get("to")}, noThread)
+	}
+}
+
+// handleChatThread shows conversation with user from path, e.g. /chat/2
+func (s *Server) handleChatThread() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "chat.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		counterpartID, err := strconv.Atoi(strings.TrimPrefix(request.URL.Path, ChatThread))
+		if err != nil || counterpartID < 0 {
+			http.NotFound(writer, request)
+			return
+		}
+		s.renderChat(writer, request, tpl, chatPage{
+			RecipientID: strconv.Itoa(counterpartID),
+			Err:         request.URL.Query().Get("err"),
+		}, counterpartID)
+	}
+}
+
+func (s *Server) handleChatSend() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "chat.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse message form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		page := chatPage{
+			RecipientID: strings.TrimSpace(request.PostFormValue("recipient")),
+			Text:        request.PostFormValue("message"),
+		}
+		recipientID, err := strconv.Atoi(page.RecipientID)
+		if err != nil {
+			page.Err = "chat.recipient"
+			writer.WriteHeader(http.StatusBadRequest)
+			s.renderChat(writer, request, tpl, page, noThread)
+			return
+		}
+
+		sent, err := s.chatSvc.SendMessage(request.Context(), chat.ModelMassage{
+			SenderID:    payload.Id,
+			RecipientID: recipientID,
+			Message:     page.Text,
+		}, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't send message from %d to %d: %v", payload.Id, recipientID, err)
+			page.Err = chatErrCode(err)
+			writer.WriteHeader(http.StatusBadRequest)
+			s.renderChat(writer, request, tpl, page, recipientID)
+			return
+		}
+		s.notifyMessage(request.Context(), sent)
+		http.Redirect(writer, request, ChatThread+strconv.Itoa(recipientID), http.StatusSeeOther)
+	}
+}
+
+// noThread is passed to renderChat when only list of conversations is shown
+const noThread = -1
+
+// renderChat shows conversations of user and thread with counterpartID
+func (s *Server) renderChat(writer http.ResponseWriter, request *http.Request, tpl *template.Template, page chatPage, counterpartID int) {
+	payload, ok := payloadFromContext(request.Context())
+	if !ok {
+		http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+		return
+	}
+	token, err := request.Cookie("token")
+	if err != nil {
+		logging.Warn(request.Context(), "can't token in cookie")
+		http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+		return
+	}
+	messages, err := s.chatSvc.GetAllMessage(request.Context(), token.Value)
+	if err != nil {
+		logging.Errorf(request.Context(), "can't get messages: %v", err)
+		http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+		return
+	}
+
+	page.UserID = payload.Id
+	page.Conversations = chat.Conversations(messages, payload.Id)
+	page.LastID = chat.LastID(messages)
+	if counterpartID != noThread {
+		thread := chat.Thread(messages, payload.Id, counterpartID)
+		page.Thread = &thread
+		// money may be sent only to customers
+		if counterpartID != 0 && counterpartID != payload.Id {
+			page.Cards, err = s.cardsSvc.AllCards(request.Context(), token.Value)
+			if err != nil {
+				logging.Errorf(request.Context(), "can't get cards: %v", err)
+			}
+		}
+	}
+	err = tpl.Execute(writer, page)
+	if err != nil {
+		logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+	}
+}
+
+// chatRecipientNumber returns number of card of chat counterpart for transfer from card idCard
+func (s *Server) chatRecipientNumber(ctx context.Context, payload *Payload, idCard string, chatID string, token string) (string, error) {
+	counterpartID, err := strconv.Atoi(chatID)
+	if err != nil || counterpartID <= 0 || counterpartID == payload.Id {
+		return "", fmt.Errorf("%w: %s", chat.ErrBadRecipient, chatID)
+	}
+	sender, err := s.ownedCard(ctx, payload, idCard, actionTransfer, token)
+	if err != nil {
+		return "", err
+	}
+	recipient, err := s.cardsSvc.ReceivingCard(ctx, counterpartID, sender.Currency, token)
+	if err != nil {
+		return "", fmt.Errorf("can't get card of user %d: %w", counterpartID, err)
+	}
+	return string(recipient.Number), nil
+}
+
+// postPayment tells counterpart in chat about transfer, transfer is already done so error is only logged
+func (s *Server) postPayment(ctx context.Context, payload *Payload, chatID string, amount money.Money, transferErr error, token string) {
+	counterpartID, err := strconv.Atoi(chatID)
+	if err != nil {
+		logging.Warnf(ctx, "bad chat in transfer confirmation: %s", chatID)
+		return
+	}
+	status := chat.PaymentCompleted
+	if transferErr != nil {
+		status = chat.PaymentFailed
+	}
+	_, err = s.chatSvc.SendMessage(ctx, chat.PaymentMessage(payload.Id, counterpartID, amount, status), token)
+	if err != nil {
+		logging.Errorf(ctx, "can't post payment to chat with %d: %v", counterpartID, err)
+	}
+}
+
+func chatErrCode(err error) string {
+	switch {
+	case errors.Is(err, chat.ErrEmptyMessage):
+		return "chat.empty"
+	case errors.Is(err, chat.ErrLongMessage):
+		return "chat.long"
+	case errors.Is(err, chat.ErrBadRecipient), errors.Is(err, chat.ErrRecipientIsYou), errors.Is(err, chat.ErrResponse):
+		return "chat.recipient"
+	default:
+		return "chat.send"
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/chat"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// chat service can't push and has no "since" filter, so every open stream downloads
+	// whole history of user each interval: n tabs cost n/2 requests per second and
+	// traffic grows with history. Interval is the trade-off between delay and that load
+	chatPollInterval = 2 * time.Second
+	// proxies close idle connections, comment line keeps stream alive
+	chatKeepAlive = 15 * time.Second
+	// browser waits so long before reconnect
+	chatRetry = 3 * time.Second
+	// every stream polls chat service, so tabs of one user can't multiply load without bound
+	chatMaxStreams = 3
+)
+
+// chatEvent is data of event sent to browser
+type chatEvent struct {
+	chat.ModelMassage
+	CounterpartID int  `json:"counterpart_id"`
+	Outgoing      bool `json:"outgoing"`
+}
+
+// handleChatEvents streams new messages of user as server-sent events. Event id is message id,
+// so browser sends Last-Event-ID on reconnect and gets messages it missed. Page passes id of its
+// newest message in "last", so messages sent between render and connect aren't lost.
+// User gets 429 for stream over chatMaxStreams, page still works without live updates
+func (s *Server) handleChatEvents() http.HandlerFunc {
+	// open streams by user id
+	var mutex sync.Mutex
+	streams := make(map[int]int)
+	acquire := func(userID int) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if streams[userID] >= chatMaxStreams {
+			return false
+		}
+		streams[userID]++
+		return true
+	}
+	release := func(userID int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		streams[userID]--
+		if streams[userID] == 0 {
+			delete(streams, userID)
+		}
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		flusher, ok := writer.(http.Flusher)
+		if !ok {
+			logging.Error(request.Context(), "can't stream chat events: response writer isn't flusher")
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		lastID := -1
+		lastEventID := request.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = request.URL.Query().Get("last")
+		}
+		if lastEventID != "" {
+			lastID, err = strconv.Atoi(lastEventID)
+			if err != nil || lastID < 0 {
+				http.Error(writer, "bad Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if !acquire(payload.Id) {
+			logging.Warnf(request.Context(), "user %d has %d chat streams already", payload.Id, chatMaxStreams)
+			writer.Header().Set("Retry-After", strconv.Itoa(int(chatRetry.Seconds())))
+			http.Error(writer, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		defer release(payload.Id)
+
+		writer.Header().Set("Content-Type", "text/event-stream")
+		writer.Header().Set("Cache-Control", "no-cache")
+		writer.Header().Set("Connection", "keep-alive")
+		writer.Header().Set("X-Accel-Buffering", "no")
+		writer.WriteHeader(http.StatusOK)
+		_, err = fmt.Fprintf(writer, "retry: %d\n\n", chatRetry.Milliseconds())
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+
+		// stream ends with token, browser reconnects with new cookie or gets 401
+		expired := time.NewTimer(time.Until(time.Unix(payload.Exp, 0)))
+		defer expired.Stop()
+		ticker := time.NewTicker(chatPollInterval)
+		defer ticker.Stop()
+		keepAlive := time.NewTicker(chatKeepAlive)
+		defer keepAlive.Stop()
+
+		// poll sends messages after lastID, false means browser is gone
+		poll := func() bool {
+			messages, err := s.chatSvc.GetAllMessage(request.Context(), token.Value)
+			if err != nil {
+				logging.Errorf(request.Context(), "can't poll messages of user %d: %v", payload.Id, err)
+				return true
+			}
+			if lastID == -1 {
+				// stream without Last-Event-ID and last starts from now
+				lastID = chat.LastID(messages)
+				return true
+			}
+			for _, message := range chat.Since(messages, payload.Id, lastID) {
+				err = writeChatEvent(writer, payload.Id, message)
+				if err != nil {
+					return false
+				}
+				lastID = message.ID
+			}
+			flusher.Flush()
+			return true
+		}
+
+		if !poll() {
+			return
+		}
+		for {
+			select {
+			case <-request.Context().Done():
+				return
+			case <-expired.C:
+				return
+			case <-keepAlive.C:
+				_, err = fmt.Fprint(writer, ": keep-alive\n\n")
+				if err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-ticker.C:
+				if !poll() {
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeChatEvent(writer http.ResponseWriter, userID int, message chat.ModelMassage) error {
+	data, err := json.Marshal(chatEvent{
+		ModelMassage:  message,
+		CounterpartID: message.Counterpart(userID),
+		Outgoing:      message.IsOutgoing(userID),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(writer, "id: %d\nevent: message\ndata: %s\n\n", message.ID, data)
+	return err
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"github.com/jafarsirojov/bank-front/pkg/core/devices"
+	"github.com/jafarsirojov/bank-front/pkg/core/notifications"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const deviceCookie = "device"
+
+// device cookie lives longer than token, so next login is recognized
+const deviceCookieAge = 365 * 24 * time.Hour
+
+// checkDevice remembers browser of login and alerts user when it is new
+func (s *Server) checkDevice(writer http.ResponseWriter, request *http.Request, payload Payload) {
+	if payload.Phone != 0 {
+		err := s.notifier.Store().DefaultPhone(payload.Id, strconv.Itoa(payload.Phone))
+		if err != nil {
+			logging.Errorf(request.Context(), "can't set phone of %d for notifications: %v", payload.Id, err)
+		}
+	}
+
+	var id string
+	if cookie, err := request.Cookie(deviceCookie); err == nil && devices.ValidID(cookie.Value) {
+		id = cookie.Value
+	} else {
+		id, err = devices.NewID()
+		if err != nil {
+			logging.Errorf(request.Context(), "%v", err)
+			return
+		}
+		http.SetCookie(writer, &http.Cookie{
+			Name:     deviceCookie,
+			Value:    id,
+			Path:     Root,
+			MaxAge:   int(deviceCookieAge / time.Second),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	ip, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		ip = request.RemoteAddr
+	}
+	device := devices.Device{
+		ID:        id,
+		UserID:    payload.Id,
+		UserAgent: request.UserAgent(),
+		IP:        ip,
+	}
+	isNew, hadOthers, err := s.devicesSvc.Seen(device, time.Now())
+	if err != nil {
+		logging.Errorf(request.Context(), "can't remember device of %d: %v", payload.Id, err)
+	}
+	if !isNew || !hadOthers {
+		return
+	}
+	s.notifier.Notify(request.Context(), notifications.Notification{
+		UserID: payload.Id,
+		Kind:   notifications.KindNewDevice,
+		Title:  "Вход с нового устройства",
+		Body:   "В аккаунт вошли с браузера " + device.UserAgent + ", IP " + device.IP + ". Если это были не вы, смените пароль",
+		Data: map[string]string{
+			"device": device.UserAgent,
+			"ip":     device.IP,
+		},
+	})
+}
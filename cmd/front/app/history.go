@@ -0,0 +1,272 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/history"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *Server) handleHistoryReceipt() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		id, err := strconv.Atoi(request.URL.Query().Get("id"))
+		if err != nil {
+			http.NotFound(writer, request)
+			return
+		}
+
+		allCards, err := s.cardsSvc.AllCards(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		operation, err := s.historySvc.Operation(request.Context(), id, token.Value)
+		if err != nil {
+			if errors.Is(err, history.ErrOperationNotFound) {
+				http.NotFound(writer, request)
+				return
+			}
+			logging.Errorf(request.Context(), "can't get operation %d: %v", id, err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		operations := []history.ModelOperationsLog{operation}
+		history.SetCurrencies(operations, cardCurrencies(allCards))
+
+		// document is rendered to buffer first, so failure can still be reported with status
+		var document bytes.Buffer
+		err = operations[0].Receipt(&document, time.Now().In(userLocation(request)))
+		if err != nil {
+			logging.Errorf(request.Context(), "can't render receipt %d: %v", id, err)
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/pdf")
+		writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="receipt-%d.pdf"`, id))
+		writer.Header().Set("Content-Length", strconv.Itoa(document.Len()))
+		_, err = document.WriteTo(writer)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't write receipt %d: %v", id, err)
+		}
+	}
+}
+
+type historyPage struct {
+	Cards      []cards.Cards
+	Operations []history.ModelOperationsLog
+	Form       url.Values
+	Next       string
+	Location   *time.Location
+	Err        string
+}
+
+func (s *Server) handleHistory() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "history.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		allCards, err := s.cardsSvc.AllCards(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		form := request.URL.Query()
+		page := historyPage{Cards: allCards, Form: form, Location: userLocation(request)}
+		filter, err := historyFilter(form, allCards, page.Location)
+		if err != nil {
+			page.Err = err.Error()
+			writer.WriteHeader(http.StatusBadRequest)
+		} else {
+			limit, _ := strconv.Atoi(form.Get("limit"))
+			result, err := s.historySvc.Page(request.Context(), history.Query{
+				Filter:     filter,
+				Cursor:     form.Get("cursor"),
+				Limit:      limit,
+				Currencies: cardCurrencies(allCards),
+			}, token.Value)
+			switch {
+			case errors.Is(err, history.ErrBadCursor):
+				http.Redirect(writer, request, History, http.StatusSeeOther)
+				return
+			case err != nil:
+				logging.Errorf(request.Context(), "can't get history: %v", err)
+				http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+				return
+			}
+			page.Operations = result.Operations
+			if result.Next != "" {
+				next := url.Values{}
+				for key, values := range form {
+					next[key] = values
+				}
+				next.Set("cursor", result.Next)
+				page.Next = History + "?" + next.Encode()
+			}
+		}
+
+		err = tpl.Execute(writer, page)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+func (s *Server) handleHistoryExport() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		query := request.URL.Query()
+		format, err := history.ParseFormat(query.Get("format"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		allCards, err := s.cardsSvc.AllCards(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		filter, err := historyFilter(query, allCards, userLocation(request))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		operations, err := s.historySvc.AllHistory(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get history: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		statements := history.NewStatements(operations, filter, allCards)
+
+		now := time.Now()
+		writer.Header().Set("Content-Type", format.ContentType())
+		writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s.%s"`, now.Format("20060102"), format.Extension()))
+		err = history.Export(writer, format, statements, now)
+		if err != nil {
+			// headers are already sent, client gets truncated file
+			logging.Errorf(request.Context(), "can't export history: %v", err)
+		}
+	}
+}
+
+// historyFilter reads filter form: from, to (dates, both included), card (id), direction,
+// min and max (amounts in currency of card or default one), counterparty (part of number)
+func historyFilter(form url.Values, allCards []cards.Cards, location *time.Location) (filter history.Filter, err error) {
+	if from := form.Get("from"); from != "" {
+		filter.From, err = time.ParseInLocation("2006-01-02", from, location)
+		if err != nil {
+			return filter, errors.New("bad from date, expected YYYY-MM-DD")
+		}
+	}
+	if to := form.Get("to"); to != "" {
+		filter.To, err = time.ParseInLocation("2006-01-02", to, location)
+		if err != nil {
+			return filter, errors.New("bad to date, expected YYYY-MM-DD")
+		}
+		filter.To = filter.To.AddDate(0, 0, 1)
+	}
+
+	currency := money.DefaultCurrency
+	if id := form.Get("card"); id != "" {
+		for _, card := range allCards {
+			if id == strconv.Itoa(card.Id) {
+				filter.CardID = card.Id
+				filter.Number = card.Number
+				currency = card.Currency
+			}
+		}
+		if filter.Number == "" {
+			return filter, errors.New("unknown card")
+		}
+	}
+
+	switch direction := history.Direction(form.Get("direction")); direction {
+	case "", history.DirectionIn, history.DirectionOut:
+		filter.Direction = direction
+	default:
+		return filter, errors.New("bad direction, expected in or out")
+	}
+
+	if min := form.Get("min"); min != "" {
+		amount, err := money.Parse(min, currency, money.DefaultLocale)
+		if err != nil {
+			return filter, errors.New("bad min amount")
+		}
+		filter.MinAmount = amount
+	}
+	if max := form.Get("max"); max != "" {
+		amount, err := money.Parse(max, currency, money.DefaultLocale)
+		if err != nil {
+			return filter, errors.New("bad max amount")
+		}
+		filter.MaxAmount = amount
+	}
+
+	filter.Counterparty = strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, form.Get("counterparty"))
+	return filter, nil
+}
+
+func cardCurrencies(allCards []cards.Cards) map[cards.Number]money.Currency {
+	currencies := make(map[cards.Number]money.Currency, len(allCards))
+	for _, card := range allCards {
+		currencies[card.Number] = card.Currency
+	}
+	return currencies
+}
+
+// userLocation is time zone of browser, history page saves it to cookie
+func userLocation(request *http.Request) *time.Location {
+	cookie, err := request.Cookie("tz")
+	if err != nil {
+		return time.Local
+	}
+	name, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		return time.Local
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		return time.Local
+	}
+	return location
+}
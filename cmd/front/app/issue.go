@@ -0,0 +1,210 @@
+package app
+
+import (
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxCardNameLength = 40
+
+type issuePage struct {
+	Currencies []money.Currency
+	Name       string
+	Currency   money.Currency
+	Balance    string
+	OwnerID    string
+	Reason     string
+	Errors     map[string]string
+	Failure    string
+	Done       bool
+}
+
+func newIssuePage() issuePage {
+	return issuePage{
+		Currencies: money.Currencies(),
+		Currency:   money.DefaultCurrency,
+		Errors:     make(map[string]string),
+	}
+}
+
+// parse reads fields common for customer and back office forms
+func (p *issuePage) parse(request *http.Request) {
+	p.Name = strings.TrimSpace(request.PostFormValue("name"))
+	switch {
+	case p.Name == "":
+		p.Errors["name"] = "err.required"
+	case utf8.RuneCountInString(p.Name) > maxCardNameLength:
+		p.Errors["name"] = "err.length"
+	}
+	currency, err := money.ParseCurrency(request.PostFormValue("currency"))
+	if err != nil {
+		p.Errors["currency"] = "err.format"
+		return
+	}
+	p.Currency = currency
+}
+
+// handleAddCardPage shows form of virtual card issue, card is always opened for user from token
+func (s *Server) handleAddCardPage() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "addcard.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		err := tpl.Execute(writer, newIssuePage())
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+func (s *Server) handleAddCard() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "addcard.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse add card form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		page := newIssuePage()
+		page.parse(request)
+		if len(page.Errors) == 0 {
+			// new card is empty, money comes only by transfer
+			err = s.cardsSvc.AddCard(request.Context(), page.Name, money.New(0, page.Currency), payload.Id, token.Value)
+			if err == nil {
+				http.Redirect(writer, request, Profile, http.StatusSeeOther)
+				return
+			}
+			logging.Errorf(request.Context(), "can't add card for user %d: %v", payload.Id, err)
+			page.Failure = "err.issue"
+		}
+
+		writer.WriteHeader(http.StatusBadRequest)
+		err = tpl.Execute(writer, page)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+// handleAdminCardPage is back office form to open card with balance for any user
+func (s *Server) handleAdminCardPage() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "admincard.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		if payload.Id != 0 {
+			s.audit(request.Context(), payload, actionManual, "", "back office form requested by customer")
+			http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		page := newIssuePage()
+		page.Done = request.URL.Query().Get("ok") != ""
+		err := tpl.Execute(writer, page)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+func (s *Server) handleAdminCard() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "admincard.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		if payload.Id != 0 {
+			s.audit(request.Context(), payload, actionManual, "", "back office form posted by customer")
+			http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse admin card form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		page := newIssuePage()
+		page.parse(request)
+		page.Balance = request.PostFormValue("balance")
+		page.OwnerID = strings.TrimSpace(request.PostFormValue("ownerid"))
+		page.Reason = strings.TrimSpace(request.PostFormValue("reason"))
+		balance := money.New(0, page.Currency)
+		if page.Balance != "" {
+			balance, err = money.Parse(page.Balance, page.Currency, money.DefaultLocale)
+			if err != nil || balance.IsNegative() {
+				page.Errors["balance"] = "err.format"
+			}
+		}
+		ownerID, err := strconv.Atoi(page.OwnerID)
+		if err != nil || ownerID <= 0 {
+			page.Errors["ownerid"] = "err.format"
+		}
+		if page.Reason == "" {
+			page.Errors["reason"] = "err.required"
+		}
+
+		if len(page.Errors) == 0 {
+			err = s.cardsSvc.AddCard(request.Context(), page.Name, balance, ownerID, token.Value)
+			if err == nil {
+				s.audit(request.Context(), payload, actionManual, "",
+					fmt.Sprintf("card %q for user %d with balance %s: %s", page.Name, ownerID, balance, page.Reason))
+				http.Redirect(writer, request, AdminCard+"?ok=1", http.StatusSeeOther)
+				return
+			}
+			logging.Errorf(request.Context(), "can't add card for user %d: %v", ownerID, err)
+			page.Failure = "err.issue"
+		}
+
+		writer.WriteHeader(http.StatusBadRequest)
+		err = tpl.Execute(writer, page)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/history"
+	"github.com/jafarsirojov/bank-front/pkg/core/limits"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) handleCardLimits() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse limits form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		card, err := s.ownedCard(request.Context(), payload, request.PostFormValue("id"), actionLimits, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't set limits: %v", err)
+			if errors.Is(err, ErrNotOwner) {
+				http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		cardLimits := limits.Limits{
+			CardID:         card.Id,
+			OwnerID:        payload.Id,
+			OnlineDisabled: request.PostFormValue("onlineDisabled") != "",
+		}
+		for name, target := range map[string]*int64{
+			"perTransaction": &cardLimits.PerTransaction,
+			"daily":          &cardLimits.Daily,
+			"monthly":        &cardLimits.Monthly,
+		} {
+			value := request.PostFormValue(name)
+			if value == "" {
+				continue
+			}
+			amount, err := money.Parse(value, card.Currency, money.DefaultLocale)
+			if err != nil || amount.IsNegative() {
+				http.Redirect(writer, request, CardPage+strconv.Itoa(card.Id)+"?err=limit.format", http.StatusSeeOther)
+				return
+			}
+			*target = amount.Amount
+		}
+
+		_, err = s.limitsSvc.Set(cardLimits, time.Now())
+		if err != nil {
+			logging.Errorf(request.Context(), "can't set limits of card %d: %v", card.Id, err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		http.Redirect(writer, request, CardPage+strconv.Itoa(card.Id), http.StatusSeeOther)
+	}
+}
+
+// checkLimits returns *limits.Exceeded when card owner doesn't allow to spend amount,
+// online is true for payments to merchants
+func (s *Server) checkLimits(ctx context.Context, card cards.Cards, amount money.Money, online bool, token string) error {
+	cardLimits := s.limitsSvc.Get(card.Id)
+	if cardLimits.IsZero() {
+		return nil
+	}
+	spent, err := s.spent(ctx, card, time.Now(), token)
+	if err != nil {
+		return err
+	}
+	return cardLimits.Check(amount, spent, online)
+}
+
+// spent sums debits of card in current day and month by history
+func (s *Server) spent(ctx context.Context, card cards.Cards, now time.Time, token string) (limits.Spent, error) {
+	operations, err := s.historySvc.AllHistory(ctx, token)
+	if err != nil {
+		return limits.Spent{}, fmt.Errorf("can't get history for limits: %w", err)
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	filter := history.Filter{From: month, Number: card.Number, Direction: history.DirectionOut}
+	// history sends whole units, limits are in minor units of card currency
+	history.SetCurrencies(operations, cardCurrencies([]cards.Cards{card}))
+
+	var spent limits.Spent
+	for _, operation := range operations {
+		if !filter.Match(operation) {
+			continue
+		}
+		spent.Month += operation.Count.Amount
+		if !operation.Moment().Before(day) {
+			spent.Day += operation.Count.Amount
+		}
+	}
+	return spent, nil
+}
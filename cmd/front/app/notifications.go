@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/chat"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/notifications"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// text of message in notification is cut, whole message is in chat
+const notificationPreviewLength = 100
+
+type notificationsPage struct {
+	Notifications []notifications.Notification
+	Unread        int
+	Channels      []string
+	Settings      notifications.Settings
+	Saved         bool
+	Err           string
+}
+
+func (s *Server) handleNotifications() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "notifications.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		store := s.notifier.Store()
+		err := tpl.Execute(writer, notificationsPage{
+			Notifications: store.List(payload.Id),
+			Unread:        store.Unread(payload.Id),
+			Channels:      s.notifier.Channels(),
+			Settings:      store.Settings(payload.Id),
+			Saved:         request.URL.Query().Get("ok") != "",
+			Err:           request.URL.Query().Get("err"),
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+// handleNotificationsUnread gives count for bell in navbar of every page
+func (s *Server) handleNotificationsUnread() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set("Cache-Control", "no-store")
+		err := json.NewEncoder(writer).Encode(struct {
+			Unread int `json:"unread"`
+		}{
+			Unread: s.notifier.Store().Unread(payload.Id),
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "can't write unread notifications: %v", err)
+		}
+	}
+}
+
+func (s *Server) handleNotificationsBell() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+		http.ServeFile(writer, request, filepath.Join("web/static", "bell.js"))
+	}
+}
+
+// handleNotificationRead marks notification as read and opens its link
+func (s *Server) handleNotificationRead() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse notification form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		id, err := strconv.ParseInt(request.PostFormValue("id"), 10, 64)
+		if err != nil {
+			http.Redirect(writer, request, Notifications, http.StatusSeeOther)
+			return
+		}
+		notification, err := s.notifier.Store().MarkRead(payload.Id, id)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't mark notification %d of %d as read: %v", id, payload.Id, err)
+			http.Redirect(writer, request, Notifications, http.StatusSeeOther)
+			return
+		}
+		// links are made by front, but check they stay on this site
+		if strings.HasPrefix(notification.Link, "/") && !strings.HasPrefix(notification.Link, "//") {
+			http.Redirect(writer, request, notification.Link, http.StatusSeeOther)
+			return
+		}
+		http.Redirect(writer, request, Notifications, http.StatusSeeOther)
+	}
+}
+
+func (s *Server) handleNotificationsReadAll() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := s.notifier.Store().MarkAllRead(payload.Id)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't mark notifications of %d as read: %v", payload.Id, err)
+		}
+		http.Redirect(writer, request, Notifications, http.StatusSeeOther)
+	}
+}
+
+func (s *Server) handleNotificationSettings() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse notification settings form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		settings := notifications.Settings{
+			Email:    request.PostFormValue("email"),
+			Phone:    request.PostFormValue("phone"),
+			Channels: make(map[string]bool),
+		}
+		// only channels configured on this front may be enabled
+		for _, channel := range s.notifier.Channels() {
+			for _, value := range request.PostForm["channel"] {
+				if value == channel {
+					settings.Channels[channel] = true
+				}
+			}
+		}
+		err = s.notifier.Store().SaveSettings(payload.Id, settings)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't save notification settings of %d: %v", payload.Id, err)
+			code := "notifications.save"
+			switch {
+			case errors.Is(err, notifications.ErrBadEmail):
+				code = "notifications.email"
+			case errors.Is(err, notifications.ErrBadPhone):
+				code = "notifications.phone"
+			}
+			http.Redirect(writer, request, Notifications+"?err="+code, http.StatusSeeOther)
+			return
+		}
+		http.Redirect(writer, request, Notifications+"?ok=1", http.StatusSeeOther)
+	}
+}
+
+// notifyTransfer tells sender and recipient about transfer, receive is in recipient currency.
+// Cards service doesn't say who owns card found by number, recipientID is 0 then and
+// only sender is notified
+func (s *Server) notifyTransfer(ctx context.Context, senderID int, sender cards.Cards, recipientID int, recipient cards.Cards, amount money.Money, receive money.Money) {
+	s.notifier.Notify(ctx, notifications.Notification{
+		UserID: senderID,
+		Kind:   notifications.KindMoneySent,
+		Title:  "Перевод выполнен",
+		Body:   fmt.Sprintf("Вы перевели %s с карты %s на карту %s", amount, sender.Number, recipient.Number),
+		Link:   History,
+		Data: map[string]string{
+			"amount":    amount.String(),
+			"card":      sender.Number.String(),
+			"recipient": recipient.Number.String(),
+		},
+	})
+	if recipientID == 0 || recipientID == senderID {
+		return
+	}
+	s.notifier.Notify(ctx, notifications.Notification{
+		UserID: recipientID,
+		Kind:   notifications.KindMoneyReceived,
+		Title:  "Поступление денег",
+		Body:   fmt.Sprintf("На карту %s поступило %s", recipient.Number, receive),
+		Link:   History,
+		Data: map[string]string{
+			"amount": receive.String(),
+			"card":   recipient.Number.String(),
+		},
+	})
+}
+
+// notifyCard tells owner that card was blocked or unblocked, owner may be not the one who did it.
+// Card without owner was found among cards of user, so it's user's card
+func (s *Server) notifyCard(ctx context.Context, payload *Payload, card cards.Cards, blocked bool) {
+	ownerID := card.OwnerID
+	if ownerID == 0 {
+		ownerID = payload.Id
+	}
+	notification := notifications.Notification{
+		UserID: ownerID,
+		Kind:   notifications.KindCardUnblocked,
+		Title:  "Карта разблокирована",
+		Body:   fmt.Sprintf("Карта %s снова работает", card.Number),
+		Link:   CardPage + strconv.Itoa(card.Id),
+		Data: map[string]string{
+			"card": card.Number.String(),
+		},
+	}
+	if blocked {
+		notification.Kind = notifications.KindCardBlocked
+		notification.Title = "Карта заблокирована"
+		notification.Body = fmt.Sprintf("Операции по карте %s запрещены. Если это были не вы, обратитесь в поддержку", card.Number)
+	}
+	s.notifier.Notify(ctx, notification)
+}
+
+// notifyMessage tells recipient about message sent from this front
+func (s *Server) notifyMessage(ctx context.Context, message chat.ModelMassage) {
+	text := message.Message
+	if utf8.RuneCountInString(text) > notificationPreviewLength {
+		text = string([]rune(text)[:notificationPreviewLength]) + "…"
+	}
+	s.notifier.Notify(ctx, notifications.Notification{
+		UserID: message.RecipientID,
+		Kind:   notifications.KindMessage,
+		Title:  fmt.Sprintf("Новое сообщение от пользователя %d", message.SenderID),
+		Body:   text,
+		Link:   ChatThread + strconv.Itoa(message.SenderID),
+		Data: map[string]string{
+			"sender": strconv.Itoa(message.SenderID),
+			"text":   message.Message,
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/audit"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"strconv"
+)
+
+var ErrNotOwner = errors.New("card doesn't belong to user")
+
+// ownedCard returns card by id from form if it belongs to user, admin may use any card.
+// Backend checks it too, but we don't send requests for foreign cards and keep audit of such attempts
+func (s *Server) ownedCard(ctx context.Context, payload *Payload, idCard string, action string, token string) (cards.Cards, error) {
+	id, err := strconv.Atoi(idCard)
+	if err != nil {
+		return cards.Cards{}, fmt.Errorf("bad card id %s: %w", idCard, err)
+	}
+	allCards, err := s.cardsSvc.AllCards(ctx, token)
+	if err != nil {
+		return cards.Cards{}, fmt.Errorf("can't get cards: %w", err)
+	}
+
+	for _, card := range allCards {
+		if card.Id != id {
+			continue
+		}
+		if payload.Id == 0 || card.OwnerID == payload.Id {
+			return card, nil
+		}
+		// card without owner can't be checked, so it's denied too
+		if card.OwnerID == 0 {
+			s.audit(ctx, payload, action, idCard, "cards service didn't send owner")
+			return cards.Cards{}, ErrNotOwner
+		}
+		s.audit(ctx, payload, action, idCard, fmt.Sprintf("card of user %d", card.OwnerID))
+		return cards.Cards{}, ErrNotOwner
+	}
+	s.audit(ctx, payload, action, idCard, "card is not in user cards")
+	return cards.Cards{}, ErrNotOwner
+}
+
+func (s *Server) audit(ctx context.Context, payload *Payload, action string, idCard string, reason string) {
+	err := s.auditLog.Record(ctx, audit.Entry{
+		UserID: payload.Id,
+		Action: action,
+		CardID: idCard,
+		Reason: reason,
+	})
+	if err != nil {
+		logging.Errorf(ctx, "can't write audit log: %v", err)
+	}
+}
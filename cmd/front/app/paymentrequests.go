@@ -0,0 +1,357 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/paymentrequests"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type requestsPage struct {
+	Cards    []cards.Cards
+	Incoming []paymentrequests.Request
+	Outgoing []paymentrequests.Request
+	Values   map[string]string
+	Errors   map[string]string
+	Now      time.Time
+	BaseURL  string
+}
+
+type requestPayPage struct {
+	Request paymentrequests.Request
+	State   paymentrequests.Status
+	Cards   []cards.Cards
+	Payer   bool
+	Err     string
+}
+
+// handleRequests shows payment requests of user and form to request money
+func (s *Server) handleRequests() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "requests.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		s.renderRequests(writer, request, tpl, requestsPage{
+			Values: map[string]string{},
+			Errors: map[string]string{},
+		})
+	}
+}
+
+func (s *Server) handleRequestCreate() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "requests.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse payment request form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		page := requestsPage{Values: map[string]string{}, Errors: map[string]string{}}
+		for _, name := range []string{"idCard", "count", "note", "expires", "payer"} {
+			page.Values[name] = strings.TrimSpace(request.PostFormValue(name))
+		}
+		paymentRequest := paymentrequests.Request{
+			RequesterID: payload.Id,
+			Note:        page.Values["note"],
+			Link:        page.Values["payer"] == "",
+		}
+
+		card, err := s.ownedCard(request.Context(), payload, page.Values["idCard"], actionTransfer, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't use card for payment request: %v", err)
+			page.Errors["idCard"] = "err.required"
+		} else {
+			paymentRequest.Number = card.Number
+			paymentRequest.Amount, err = money.Parse(page.Values["count"], card.Currency, money.DefaultLocale)
+			if err != nil || !paymentRequest.Amount.IsPositive() {
+				page.Errors["count"] = "err.format"
+			}
+		}
+		if !paymentRequest.Link {
+			paymentRequest.PayerID, err = strconv.Atoi(page.Values["payer"])
+			if err != nil || paymentRequest.PayerID < 0 {
+				page.Errors["payer"] = "err.format"
+			}
+		}
+		if page.Values["expires"] != "" {
+			day, err := time.ParseInLocation("2006-01-02", page.Values["expires"], userLocation(request))
+			if err != nil {
+				page.Errors["expires"] = "err.format"
+			}
+			// request may be paid until end of day
+			paymentRequest.ExpiresAt = day.AddDate(0, 0, 1)
+		}
+
+		if len(page.Errors) == 0 {
+			_, err = s.requestsSvc.Create(paymentRequest, time.Now())
+			switch {
+			case err == nil:
+				http.Redirect(writer, request, Requests, http.StatusSeeOther)
+				return
+			case errors.Is(err, paymentrequests.ErrSelf):
+				page.Errors["payer"] = "request.self"
+			case errors.Is(err, paymentrequests.ErrLongNote):
+				page.Errors["note"] = "request.note"
+			case errors.Is(err, paymentrequests.ErrExpiryInPast):
+				page.Errors["expires"] = "request.expiry"
+			case errors.Is(err, money.ErrInvalidAmount):
+				page.Errors["count"] = "err.format"
+			default:
+				logging.Errorf(request.Context(), "can't create payment request: %v", err)
+				http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+				return
+			}
+		}
+		writer.WriteHeader(http.StatusBadRequest)
+		s.renderRequests(writer, request, tpl, page)
+	}
+}
+
+func (s *Server) renderRequests(writer http.ResponseWriter, request *http.Request, tpl *template.Template, page requestsPage) {
+	payload, ok := payloadFromContext(request.Context())
+	if !ok {
+		http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+		return
+	}
+	var err error
+	page.Cards, err = s.userCards(request)
+	if err != nil {
+		logging.Errorf(request.Context(), "can't get cards: %v", err)
+		http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+		return
+	}
+	page.Now = time.Now()
+	page.Incoming = s.requestsSvc.Incoming(payload.Id, page.Now)
+	page.Outgoing = s.requestsSvc.Outgoing(payload.Id)
+	page.BaseURL = baseURL(request)
+	err = tpl.Execute(writer, page)
+	if err != nil {
+		logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+	}
+}
+
+// handleRequestPage shows request to payer by token from link, e.g. /requests/pay?token=...
+func (s *Server) handleRequestPage() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "requestpay.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		paymentRequest, err := s.requestsSvc.ByToken(request.URL.Query().Get("token"))
+		// addressed request isn't shown to others, requester sees own request too
+		if err != nil || !(paymentRequest.PayableBy(payload.Id) || paymentRequest.RequesterID == payload.Id) {
+			http.NotFound(writer, request)
+			return
+		}
+		page := requestPayPage{
+			Request: paymentRequest,
+			State:   paymentRequest.State(time.Now()),
+			Payer:   paymentRequest.PayableBy(payload.Id),
+			Err:     request.URL.Query().Get("err"),
+		}
+		if page.Payer && page.State == paymentrequests.StatusPending {
+			page.Cards, err = s.userCards(request)
+			if err != nil {
+				logging.Errorf(request.Context(), "can't get cards: %v", err)
+				http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+				return
+			}
+		}
+		err = tpl.Execute(writer, page)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+// handleRequestPay shows preview of transfer for payment request, transfer is made by
+// handleTransferConfirm like any other one
+func (s *Server) handleRequestPay() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "transferpreview.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse pay request form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		paymentRequest, err := s.requestsSvc.ByToken(request.PostFormValue("token"))
+		if err != nil || !paymentRequest.PayableBy(payload.Id) {
+			http.NotFound(writer, request)
+			return
+		}
+		page := RequestPage + "?token=" + url.QueryEscape(paymentRequest.Token)
+		if paymentRequest.State(time.Now()) != paymentrequests.StatusPending {
+			http.Redirect(writer, request, page+"&err=request.state", http.StatusSeeOther)
+			return
+		}
+
+		sender, recipient, err := s.transferCards(request.Context(), payload, request.PostFormValue("idCard"), string(paymentRequest.Number), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't pay request %d: %v", paymentRequest.Id, err)
+			if errors.Is(err, ErrNotOwner) {
+				http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		if sender.Currency != paymentRequest.Amount.Currency {
+			http.Redirect(writer, request, page+"&err=request.currency", http.StatusSeeOther)
+			return
+		}
+
+		data := map[string]string{"request": strconv.FormatInt(paymentRequest.Id, 10)}
+		preview, err := s.previewTransfer(request.Context(), payload, sender, recipient, paymentRequest.Amount, page, data, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't preview payment of request %d: %v", paymentRequest.Id, err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		err = tpl.Execute(writer, preview)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+// payRequest locks request of confirmed transfer, so it isn't paid twice
+func (s *Server) payRequest(payload *Payload, requestID string) (paymentrequests.Request, error) {
+	id, err := strconv.ParseInt(requestID, 10, 64)
+	if err != nil {
+		return paymentrequests.Request{}, fmt.Errorf("bad payment request id %q: %w", requestID, paymentrequests.ErrNotFound)
+	}
+	return s.requestsSvc.Begin(id, payload.Id, time.Now())
+}
+
+// finishRequest marks request paid or returns it to pending when transfer was rejected.
+// When result of transfer is unknown request stays paying, so it isn't paid again
+func (s *Server) finishRequest(ctx context.Context, paymentRequest paymentrequests.Request, transferErr error) {
+	if transferErr != nil && !errors.Is(transferErr, cards.ErrResponse) {
+		logging.Warnf(ctx, "payment request %d stays paying, transfer result is unknown: %v", paymentRequest.Id, transferErr)
+		return
+	}
+	_, err := s.requestsSvc.Finish(paymentRequest.Id, transferErr == nil, time.Now())
+	if err != nil {
+		logging.Errorf(ctx, "can't finish payment request %d: %v", paymentRequest.Id, err)
+	}
+}
+
+func (s *Server) handleRequestDecline() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse decline form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		paymentRequest, err := s.requestsSvc.ByToken(request.PostFormValue("token"))
+		if err != nil {
+			http.NotFound(writer, request)
+			return
+		}
+		_, err = s.requestsSvc.Decline(paymentRequest.Id, payload.Id, time.Now())
+		if err != nil {
+			logging.Errorf(request.Context(), "can't decline request %d: %v", paymentRequest.Id, err)
+			if errors.Is(err, paymentrequests.ErrNotFound) {
+				http.NotFound(writer, request)
+				return
+			}
+		}
+		http.Redirect(writer, request, Profile, http.StatusSeeOther)
+	}
+}
+
+func (s *Server) handleRequestCancel() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse cancel form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		id, err := strconv.ParseInt(request.PostFormValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(writer, request)
+			return
+		}
+		_, err = s.requestsSvc.Cancel(id, payload.Id, time.Now())
+		if err != nil {
+			logging.Errorf(request.Context(), "can't cancel request %d: %v", id, err)
+			if errors.Is(err, paymentrequests.ErrNotFound) {
+				http.NotFound(writer, request)
+				return
+			}
+		}
+		http.Redirect(writer, request, Requests, http.StatusSeeOther)
+	}
+}
+
+// baseURL is used for links which are sent outside of site
+func baseURL(request *http.Request) string {
+	scheme := "http"
+	if request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + request.Host
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/limits"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/payments"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type paymentPage struct {
+	Payees  []payments.Payee
+	Payee   *payments.Payee
+	Cards   []cards.Cards
+	Values  map[string]string
+	IdCard  int
+	Count   string
+	Errors  payments.FieldErrors
+	Failure string
+	Key     string
+}
+
+func (s *Server) handlePaymentPage() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "payment.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		page := paymentPage{Payees: payments.Catalogue()}
+		if payeeID := request.URL.Query().Get("payee"); payeeID != "" {
+			payee, err := payments.PayeeByID(payeeID)
+			if err != nil {
+				http.Redirect(writer, request, Payment, http.StatusSeeOther)
+				return
+			}
+			page.Payee = &payee
+			page.Cards, err = s.userCards(request)
+			if err != nil {
+				logging.Errorf(request.Context(), "can't get cards: %v", err)
+				http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+				return
+			}
+			page.Key, err = payments.NewKey()
+			if err != nil {
+				logging.Errorf(request.Context(), "can't make payment key: %v", err)
+				http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+				return
+			}
+		}
+
+		err := tpl.Execute(writer, page)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+func (s *Server) handlePayment() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "payment.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse payment form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		payee, err := payments.PayeeByID(request.PostFormValue("payee"))
+		if err != nil {
+			http.Redirect(writer, request, Payment, http.StatusSeeOther)
+			return
+		}
+		allCards, err := s.userCards(request)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		page := paymentPage{
+			Payees: payments.Catalogue(),
+			Payee:  &payee,
+			Cards:  allCards,
+			Values: make(map[string]string),
+			Count:  request.PostFormValue("count"),
+			Errors: payments.FieldErrors{},
+			Key:    request.PostFormValue("key"),
+		}
+		for _, field := range payee.Fields {
+			page.Values[field.Name] = request.PostFormValue(field.Name)
+		}
+
+		page.IdCard, _ = strconv.Atoi(request.PostFormValue("idCard"))
+		var card *cards.Cards
+		for i := range allCards {
+			if allCards[i].Id == page.IdCard {
+				card = &allCards[i]
+			}
+		}
+		if card == nil {
+			page.Errors["idCard"] = "err.required"
+		}
+
+		amount, err := money.Parse(page.Count, payee.Currency, money.DefaultLocale)
+		if err != nil {
+			page.Errors["amount"] = "err.format"
+		}
+
+		var fields map[string]string
+		if len(page.Errors) == 0 {
+			fields, err = payee.Validate(page.Values, amount)
+			if err != nil {
+				var fieldErrs payments.FieldErrors
+				if errors.As(err, &fieldErrs) {
+					page.Errors = fieldErrs
+				}
+			}
+		}
+		if len(page.Errors) == 0 && card.Currency != payee.Currency {
+			page.Errors["idCard"] = "err.currency"
+		}
+		if len(page.Errors) == 0 {
+			balance, err := card.Balance.Sub(amount)
+			if err != nil || balance.IsNegative() {
+				page.Errors["amount"] = "err.balance"
+			}
+		}
+		if len(page.Errors) == 0 {
+			err = s.checkLimits(request.Context(), *card, amount, true, token.Value)
+			var exceeded *limits.Exceeded
+			switch {
+			case errors.As(err, &exceeded):
+				page.Errors["amount"] = exceeded.Code()
+			case err != nil:
+				logging.Errorf(request.Context(), "can't check limits: %v", err)
+				http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+				return
+			}
+		}
+
+		if len(page.Errors) == 0 {
+			// key of form is paid only once, second submit shows receipt of first one
+			receipt, err := s.receiptsSvc.Begin(payload.Id, page.Key)
+			switch {
+			case errors.Is(err, payments.ErrPaid):
+				http.Redirect(writer, request, PaymentReceipt+"?id="+url.QueryEscape(receipt.Id), http.StatusSeeOther)
+				return
+			case errors.Is(err, payments.ErrInProgress):
+				page.Failure = "err.inprogress"
+			case err != nil:
+				logging.Warnf(request.Context(), "can't begin payment: %v", err)
+				http.Redirect(writer, request, Payment+"?payee="+url.QueryEscape(payee.Id), http.StatusSeeOther)
+				return
+			}
+		}
+
+		if len(page.Errors) == 0 && page.Failure == "" {
+			id, err := s.paymentsSvc.Pay(request.Context(), card.Id, payee, fields, amount, token.Value)
+			if err == nil {
+				receipt, err := s.receiptsSvc.Add(payments.Receipt{
+					Id:         id,
+					OwnerID:    payload.Id,
+					PayeeID:    payee.Id,
+					PayeeName:  payee.Name,
+					Fields:     fields,
+					CardNumber: card.Number,
+					Amount:     amount,
+					Time:       time.Now(),
+					Key:        page.Key,
+				})
+				if err == nil {
+					http.Redirect(writer, request, PaymentReceipt+"?id="+url.QueryEscape(receipt.Id), http.StatusSeeOther)
+					return
+				}
+				// payment is made, but there is no receipt to show
+				logging.Errorf(request.Context(), "can't save receipt %s: %v", receipt.Id, err)
+				page.Failure = "err.receipt"
+			} else {
+				logging.Errorf(request.Context(), "can't pay %s: %v", payee.Id, err)
+				// payment could be made if cards service didn't answer, key stays busy then
+				page.Failure = "err.unknown"
+				if errors.Is(err, payments.ErrResponse) {
+					s.receiptsSvc.Abort(payload.Id, page.Key)
+					page.Failure = "err.payment"
+				}
+				var typedErr *payments.ErrorResponse
+				if errors.As(err, &typedErr) {
+					page.Failure = typedErr.Error()
+				}
+			}
+		}
+
+		writer.WriteHeader(http.StatusBadRequest)
+		err = tpl.Execute(writer, page)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+func (s *Server) handlePaymentReceipt() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "receipt.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		receipt, err := s.receiptsSvc.Get(payload.Id, request.URL.Query().Get("id"))
+		if err != nil {
+			http.NotFound(writer, request)
+			return
+		}
+		err = tpl.Execute(writer, receipt)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+// userCards returns cards of user from token cookie
+func (s *Server) userCards(request *http.Request) ([]cards.Cards, error) {
+	token, err := request.Cookie("token")
+	if err != nil {
+		return nil, err
+	}
+	return s.cardsSvc.AllCards(request.Context(), token.Value)
+}
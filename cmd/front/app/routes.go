@@ -10,24 +10,63 @@ import (
 )
 
 var (
-	Root      = "/"
-	Login     = "/login"
-	Logout    = "/logout"
-	Profile   = "/profile"
-	Chat      = "/message"
-	Transfer  = "/transfer"
-	Payment   = "/payment"
-	Register  = "/register"
-	AddCard   = "/add/card"
-	ErrorPage = "/page/error/client"
-	Block     = "/card/block"
-	UnBlock   = "/card/unblock"
+	Root            = "/"
+	Login           = "/login"
+	Logout          = "/logout"
+	Profile         = "/profile"
+	Chat            = "/chat"
+	ChatThread      = "/chat/"
+	ChatSend        = "/chat/send"
+	ChatEvents      = "/chat/events"
+	ChatAttach      = "/chat/attach"
+	ChatAttachment  = "/chat/attachments/"
+	Support         = "/support"
+	SupportTicket   = "/support/"
+	SupportOpen     = "/support/open"
+	SupportReply    = "/support/reply"
+	SupportClaim    = "/support/claim"
+	SupportClose    = "/support/close"
+	AgentConsole    = "/agent"
+	Notifications   = "/notifications"
+	NotificationsJS = "/notifications/bell.js"
+	NotifyUnread    = "/notifications/unread"
+	NotifyRead      = "/notifications/read"
+	NotifyReadAll   = "/notifications/read-all"
+	NotifySettings  = "/notifications/settings"
+	Requests        = "/requests"
+	RequestCreate   = "/requests/create"
+	RequestPage     = "/requests/pay"
+	RequestDecline  = "/requests/decline"
+	RequestCancel   = "/requests/cancel"
+	Transfer        = "/transfer"
+	TransferConfirm = "/transfer/confirm"
+	Beneficiaries   = "/beneficiaries"
+	BeneficiaryAdd  = "/beneficiaries/add"
+	BeneficiaryEdit = "/beneficiaries/edit"
+	BeneficiaryDel  = "/beneficiaries/delete"
+	Schedules       = "/schedules"
+	ScheduleCreate  = "/schedules/create"
+	ScheduleCancel  = "/schedules/cancel"
+	Payment         = "/payment"
+	PaymentReceipt  = "/payment/receipt"
+	History         = "/history"
+	HistoryReceipt  = "/history/receipt"
+	HistoryExport   = "/history/export"
+	Analytics       = "/analytics"
+	Register        = "/register"
+	AddCard         = "/add/card"
+	AdminCard       = "/admin/card"
+	CardPage        = "/cards/"
+	ErrorPage       = "/page/error/client"
+	Block           = "/card/block"
+	UnBlock         = "/card/unblock"
+	CardLimits      = "/card/limits"
 )
 
 func (s *Server) InitRoutes() {
 	jwtMW := jwt.JWT(jwtmux.SourceCookie, reflect.TypeOf((*Payload)(nil)).Elem(), s.secret)
 	authMW := authenticated.Authenticated(jwt.IsContextNonEmpty, true, Root)
-	authOKMW := authenticated.Authenticated(func(ctx context.Context) bool {return !jwt.IsContextNonEmpty(ctx)}, true, Profile)
+	authOKMW := authenticated.Authenticated(func(ctx context.Context) bool { return !jwt.IsContextNonEmpty(ctx) }, true, Profile)
 	s.router.GET(Root, s.handleFrontPage(), authOKMW, jwtMW, logger.Logger("HTTP"))
 	// GET -> html
 
@@ -41,32 +80,78 @@ func (s *Server) InitRoutes() {
 	s.router.GET(Profile, s.handleProfile(), authMW, jwtMW, logger.Logger("HTTP"))
 	s.router.POST(Profile, s.handleProfile(), authMW, jwtMW, logger.Logger("HTTP"))
 
-	s.router.GET(Transfer, s.handleTransferPage(), jwtMW, logger.Logger("HTTP"))
-	s.router.POST(Transfer, s.handleTransfer(), jwtMW, logger.Logger("HTTP"))
+	s.router.GET(Transfer, s.handleTransferPage(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(Transfer, s.handleTransfer(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(TransferConfirm, s.handleTransferConfirm(), authMW, jwtMW, logger.Logger("HTTP"))
 
-	s.router.GET(Block, s.handleBlockPage(), jwtMW, logger.Logger("HTTP"))
-	s.router.POST(Block, s.handleBlock(), jwtMW, logger.Logger("HTTP"))
+	s.router.GET(Beneficiaries, s.handleBeneficiaries(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(BeneficiaryAdd, s.handleBeneficiaryAdd(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(BeneficiaryEdit, s.handleBeneficiaryEdit(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(BeneficiaryDel, s.handleBeneficiaryDelete(), authMW, jwtMW, logger.Logger("HTTP"))
 
-	s.router.GET(UnBlock, s.handleUnBlockPage(), jwtMW, logger.Logger("HTTP"))
-	s.router.POST(UnBlock, s.handleUnBlock(), jwtMW, logger.Logger("HTTP"))
+	s.router.GET(Schedules, s.handleSchedules(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(ScheduleCreate, s.handleScheduleCreate(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(ScheduleCancel, s.handleScheduleCancel(), authMW, jwtMW, logger.Logger("HTTP"))
+
+	s.router.GET(Block, s.handleBlockPage(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(Block, s.handleBlock(), authMW, jwtMW, logger.Logger("HTTP"))
+
+	s.router.GET(UnBlock, s.handleUnBlockPage(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(UnBlock, s.handleUnBlock(), authMW, jwtMW, logger.Logger("HTTP"))
+
+	s.router.POST(CardLimits, s.handleCardLimits(), authMW, jwtMW, logger.Logger("HTTP"))
 
 	s.router.GET(Register, s.handleRegisterPage(), logger.Logger("HTTP"))
 	s.router.POST(Register, s.handleRegister(), logger.Logger("HTTP"))
 
-	s.router.GET(AddCard, s.handleAddCardPage(), logger.Logger("HTTP"))
-	s.router.POST(AddCard, s.handleAddCard(), logger.Logger("HTTP"))
+	s.router.GET(AddCard, s.handleAddCardPage(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(AddCard, s.handleAddCard(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(AdminCard, s.handleAdminCardPage(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(AdminCard, s.handleAdminCard(), authMW, jwtMW, logger.Logger("HTTP"))
 
-	s.router.GET(Payment, s.handlePayment(), jwtMW, logger.Logger("HTTP"))
-	s.router.POST(Payment, s.handlePayment(), jwtMW, logger.Logger("HTTP"))
+	s.router.GET(Payment, s.handlePaymentPage(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(Payment, s.handlePayment(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(PaymentReceipt, s.handlePaymentReceipt(), authMW, jwtMW, logger.Logger("HTTP"))
+
+	s.router.GET(Requests, s.handleRequests(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(RequestCreate, s.handleRequestCreate(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(RequestPage, s.handleRequestPage(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(RequestPage, s.handleRequestPay(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(RequestDecline, s.handleRequestDecline(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(RequestCancel, s.handleRequestCancel(), authMW, jwtMW, logger.Logger("HTTP"))
+
+	s.router.GET(History, s.handleHistory(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(HistoryReceipt, s.handleHistoryReceipt(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(HistoryExport, s.handleHistoryExport(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(Analytics, s.handleAnalytics(), authMW, jwtMW, logger.Logger("HTTP"))
 
 	s.router.GET("/cards", s.handleCardsPage(), jwtMW, logger.Logger("HTTP"))
+	s.router.GET(CardPage, s.handleCard(), authMW, jwtMW, logger.Logger("HTTP"))
 	//s.router.GET("/cards", s.handleCards(), jwtMW, logger.Logger("HTTP"))
 	s.router.POST("/cards", s.handleCards(), jwtMW, logger.Logger("HTTP"))
 
 	// chat service
-	s.router.GET("/message/all", s.handleChat(), authMW, jwtMW, logger.Logger("HTTP"))
-	s.router.POST("/message/all", s.handleChat(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(Chat, s.handleChat(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(ChatThread, s.handleChatThread(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(ChatSend, s.handleChatSend(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(ChatEvents, s.handleChatEvents(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(ChatAttach, s.handleChatAttach(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(ChatAttachment, s.handleChatAttachment(), authMW, jwtMW, logger.Logger("HTTP"))
+
+	// notifications
+	s.router.GET(Notifications, s.handleNotifications(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(NotificationsJS, s.handleNotificationsBell(), logger.Logger("HTTP"))
+	s.router.GET(NotifyUnread, s.handleNotificationsUnread(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(NotifyRead, s.handleNotificationRead(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(NotifyReadAll, s.handleNotificationsReadAll(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(NotifySettings, s.handleNotificationSettings(), authMW, jwtMW, logger.Logger("HTTP"))
 
-	s.router.GET(Chat, s.handleMessagePage(), authMW, jwtMW, logger.Logger("HTTP"))
-	s.router.POST(Chat, s.handleMessage(), authMW, jwtMW, logger.Logger("HTTP"))
+	// support chat
+	s.router.GET(Support, s.handleSupport(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(SupportTicket, s.handleSupportTicket(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(SupportOpen, s.handleSupportOpen(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(SupportReply, s.handleSupportReply(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(SupportClaim, s.handleSupportClaim(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.POST(SupportClose, s.handleSupportClose(), authMW, jwtMW, logger.Logger("HTTP"))
+	s.router.GET(AgentConsole, s.handleAgentConsole(), authMW, jwtMW, logger.Logger("HTTP"))
 }
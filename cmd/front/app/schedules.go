@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/beneficiaries"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/fx"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/schedules"
+	"github.com/jafarsirojov/bank-front/pkg/jwt"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+func (s *Server) handleSchedules() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "schedules.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		allCards, err := s.cardsSvc.AllCards(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get cards: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		err = tpl.Execute(writer, struct {
+			Schedules     []schedules.Schedule
+			Cards         []cards.Cards
+			Beneficiaries []beneficiaries.Beneficiary
+			Today         string
+			Err           string
+		}{
+			Schedules:     s.schedulesSvc.List(payload.Id),
+			Cards:         allCards,
+			Beneficiaries: s.beneficiariesSvc.List(payload.Id),
+			Today:         time.Now().Format("2006-01-02"),
+			Err:           request.URL.Query().Get("err"),
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+func (s *Server) handleScheduleCreate() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse schedule form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		numberCard := request.PostFormValue("numberCard")
+		if numberCard == "" {
+			numberCard = s.beneficiaryNumber(payload.Id, request.PostFormValue("beneficiary"))
+		}
+		sender, recipient, err := s.transferCards(request.Context(), payload, request.PostFormValue("idCard"), numberCard, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't resolve schedule cards: %v", err)
+			http.Redirect(writer, request, Schedules+"?err=card", http.StatusSeeOther)
+			return
+		}
+		amount, err := money.Parse(request.PostFormValue("count"), sender.Currency, money.DefaultLocale)
+		if err != nil {
+			logging.Warnf(request.Context(), "bad schedule amount: %v", err)
+			http.Redirect(writer, request, Schedules+"?err=amount", http.StatusSeeOther)
+			return
+		}
+		start, err := time.ParseInLocation("2006-01-02", request.PostFormValue("date"), time.Local)
+		if err != nil {
+			logging.Warnf(request.Context(), "bad schedule date: %v", err)
+			http.Redirect(writer, request, Schedules+"?err=date", http.StatusSeeOther)
+			return
+		}
+		day, err := strconv.Atoi(request.PostFormValue("day"))
+		if err != nil {
+			day = start.Day()
+		}
+
+		_, err = s.schedulesSvc.Create(schedules.Schedule{
+			OwnerID:    payload.Id,
+			IdCard:     sender.Id,
+			Number:     recipient.Number,
+			Amount:     amount,
+			Recurrence: schedules.Recurrence(request.PostFormValue("recurrence")),
+			Day:        day,
+			Start:      start,
+		}, time.Now())
+		if err != nil {
+			logging.Errorf(request.Context(), "can't create schedule: %v", err)
+			http.Redirect(writer, request, Schedules+"?err=schedule", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(writer, request, Schedules, http.StatusSeeOther)
+	}
+}
+
+func (s *Server) handleScheduleCancel() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse schedule form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		id, err := strconv.ParseInt(request.PostFormValue("id"), 10, 64)
+		if err != nil {
+			logging.Warnf(request.Context(), "bad schedule id: %v", err)
+			http.Redirect(writer, request, Schedules, http.StatusSeeOther)
+			return
+		}
+		err = s.schedulesSvc.Cancel(payload.Id, id)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't cancel schedule %d: %v", id, err)
+		}
+		http.Redirect(writer, request, Schedules, http.StatusSeeOther)
+	}
+}
+
+// ExecuteSchedule is called by scheduler when user is offline,
+// so short living token is issued for schedule owner
+func (s *Server) ExecuteSchedule(ctx context.Context, schedule schedules.Schedule) error {
+	owner := &Payload{
+		Id:  schedule.OwnerID,
+		Exp: time.Now().Add(5 * time.Minute).Unix(),
+	}
+	token, err := jwt.Encode(owner, s.secret)
+	if err != nil {
+		return fmt.Errorf("can't issue token: %w", err)
+	}
+
+	sender, recipient, err := s.transferCards(ctx, owner, strconv.Itoa(schedule.IdCard), string(schedule.Number), token)
+	if err != nil {
+		return err
+	}
+	if sender.Currency != schedule.Amount.Currency {
+		return fmt.Errorf("card %d currency changed to %s", sender.Id, sender.Currency)
+	}
+
+	var quote *fx.Quote
+	if recipient.Currency != sender.Currency {
+		parties := fx.Parties{OwnerID: schedule.OwnerID, SenderID: sender.Id, Recipient: string(recipient.Number)}
+		locked, err := s.quoter.Quote(ctx, parties, schedule.Amount, recipient.Currency)
+		if err != nil {
+			return err
+		}
+		locked, err = s.quoter.Take(locked.ID, parties)
+		if err != nil {
+			return err
+		}
+		quote = &locked
+	}
+
+	debit := schedule.Amount
+	if quote != nil {
+		debit = quote.Debit
+	}
+	err = s.checkLimits(ctx, sender, debit, false, token)
+	if err != nil {
+		return err
+	}
+
+	err = s.cardsSvc.Transfer(ctx, recipient.Number, strconv.Itoa(sender.Id), schedule.Amount, quote, token)
+	if errors.Is(err, cards.ErrResponse) {
+		return err
+	}
+	if err != nil {
+		// money could be moved, scheduler must not send it again
+		return fmt.Errorf("%w: %v", schedules.ErrUnknownResult, err)
+	}
+	receive := schedule.Amount
+	if quote != nil {
+		receive = quote.Receive
+	}
+	s.notifyTransfer(ctx, schedule.OwnerID, sender, recipient.OwnerID, recipient, schedule.Amount, receive)
+	return nil
+}
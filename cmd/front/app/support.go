@@ -0,0 +1,305 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/chat"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type supportPage struct {
+	Tickets []chat.Ticket
+	Subject string
+	Text    string
+	Err     string
+}
+
+type ticketPage struct {
+	Ticket chat.Ticket
+	Agent  bool
+	UserID int
+	Err    string
+}
+
+type agentPage struct {
+	AgentID int
+	Waiting []chat.Ticket
+	Mine    []chat.Ticket
+	Others  []chat.Ticket
+}
+
+// handleSupport shows support tickets of customer and form to open new one
+func (s *Server) handleSupport() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "support.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		if payload.IsAgent() {
+			http.Redirect(writer, request, AgentConsole, http.StatusTemporaryRedirect)
+			return
+		}
+		s.renderSupport(writer, request, tpl, supportPage{})
+	}
+}
+
+func (s *Server) handleSupportOpen() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "support.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse support form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		page := supportPage{
+			Subject: request.PostFormValue("subject"),
+			Text:    request.PostFormValue("message"),
+		}
+		ticket, err := s.chatSvc.OpenTicket(request.Context(), page.Subject, page.Text, token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't open ticket: %v", err)
+			page.Err = supportErrCode(err)
+			writer.WriteHeader(http.StatusBadRequest)
+			s.renderSupport(writer, request, tpl, page)
+			return
+		}
+		http.Redirect(writer, request, SupportTicket+strconv.Itoa(ticket.ID), http.StatusSeeOther)
+	}
+}
+
+func (s *Server) renderSupport(writer http.ResponseWriter, request *http.Request, tpl *template.Template, page supportPage) {
+	token, err := request.Cookie("token")
+	if err != nil {
+		logging.Warn(request.Context(), "can't token in cookie")
+		http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+		return
+	}
+	page.Tickets, err = s.chatSvc.Tickets(request.Context(), token.Value)
+	if err != nil {
+		logging.Errorf(request.Context(), "can't get tickets: %v", err)
+		http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+		return
+	}
+	err = tpl.Execute(writer, page)
+	if err != nil {
+		logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+	}
+}
+
+// handleSupportTicket shows conversation to customer or agent, e.g. /support/3, and marks it read
+func (s *Server) handleSupportTicket() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "supportticket.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		ticket, err := s.supportTicket(request.Context(), payload, strings.TrimPrefix(request.URL.Path, SupportTicket), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't show ticket: %v", err)
+			http.NotFound(writer, request)
+			return
+		}
+		if ticket.Unread(payload.IsAgent()) > 0 {
+			err = s.chatSvc.MarkRead(request.Context(), ticket.ID, token.Value)
+			if err != nil {
+				logging.Errorf(request.Context(), "can't mark ticket %d read: %v", ticket.ID, err)
+			}
+		}
+
+		err = tpl.Execute(writer, ticketPage{
+			Ticket: ticket,
+			Agent:  payload.IsAgent(),
+			UserID: payload.Id,
+			Err:    request.URL.Query().Get("err"),
+		})
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+func (s *Server) handleSupportReply() http.HandlerFunc {
+	return s.ticketAction(func(ctx context.Context, payload *Payload, ticket chat.Ticket, request *http.Request, token string) error {
+		if payload.IsAgent() {
+			err := ticket.CanAnswer(payload.Id)
+			if err != nil {
+				return err
+			}
+		}
+		return s.chatSvc.Reply(ctx, ticket.ID, request.PostFormValue("message"), token)
+	})
+}
+
+func (s *Server) handleSupportClaim() http.HandlerFunc {
+	return s.ticketAction(func(ctx context.Context, payload *Payload, ticket chat.Ticket, request *http.Request, token string) error {
+		if !payload.IsAgent() {
+			return chat.ErrForbidden
+		}
+		err := ticket.CanClaim(payload.Id)
+		if err != nil {
+			return err
+		}
+		return s.chatSvc.Claim(ctx, ticket.ID, token)
+	})
+}
+
+// handleSupportClose closes ticket by customer or by agent it's assigned to
+func (s *Server) handleSupportClose() http.HandlerFunc {
+	return s.ticketAction(func(ctx context.Context, payload *Payload, ticket chat.Ticket, request *http.Request, token string) error {
+		if payload.IsAgent() {
+			err := ticket.CanAnswer(payload.Id)
+			if err != nil {
+				return err
+			}
+		}
+		return s.chatSvc.CloseTicket(ctx, ticket.ID, token)
+	})
+}
+
+// ticketAction loads ticket from form for action, then returns to ticket page with error code if action fails
+func (s *Server) ticketAction(action func(ctx context.Context, payload *Payload, ticket chat.Ticket, request *http.Request, token string) error) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		err := request.ParseForm()
+		if err != nil {
+			logging.Errorf(request.Context(), "error while parse ticket form: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		ticket, err := s.supportTicket(request.Context(), payload, request.PostFormValue("id"), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't find ticket: %v", err)
+			http.NotFound(writer, request)
+			return
+		}
+		if ticket.IsClosed() {
+			err = chat.ErrTicketClosed
+		} else {
+			err = action(request.Context(), payload, ticket, request, token.Value)
+		}
+		page := SupportTicket + strconv.Itoa(ticket.ID)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't %s ticket %d: %v", strings.TrimPrefix(request.URL.Path, Support+"/"), ticket.ID, err)
+			page += "?err=" + supportErrCode(err)
+		}
+		http.Redirect(writer, request, page, http.StatusSeeOther)
+	}
+}
+
+// supportTicket returns ticket by id if user may see it, customer sees only own tickets
+func (s *Server) supportTicket(ctx context.Context, payload *Payload, idTicket string, token string) (chat.Ticket, error) {
+	id, err := strconv.Atoi(idTicket)
+	if err != nil {
+		return chat.Ticket{}, chat.ErrTicketNotFound
+	}
+	ticket, err := s.chatSvc.Ticket(ctx, id, token)
+	if err != nil {
+		return chat.Ticket{}, err
+	}
+	if !payload.IsAgent() && ticket.CustomerID != payload.Id {
+		return chat.Ticket{}, chat.ErrTicketNotFound
+	}
+	return ticket, nil
+}
+
+// handleAgentConsole shows support queue to agents
+func (s *Server) handleAgentConsole() http.HandlerFunc {
+	tpl, err := template.ParseFiles(filepath.Join("web/templates", "agent.gohtml"))
+	if err != nil {
+		panic(err)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		payload, ok := payloadFromContext(request.Context())
+		if !ok {
+			http.Redirect(writer, request, Root, http.StatusTemporaryRedirect)
+			return
+		}
+		if !payload.IsAgent() {
+			http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		token, err := request.Cookie("token")
+		if err != nil {
+			logging.Warn(request.Context(), "can't token in cookie")
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+		tickets, err := s.chatSvc.Tickets(request.Context(), token.Value)
+		if err != nil {
+			logging.Errorf(request.Context(), "can't get tickets: %v", err)
+			http.Redirect(writer, request, ErrorPage, http.StatusTemporaryRedirect)
+			return
+		}
+
+		page := agentPage{AgentID: payload.Id}
+		page.Waiting, page.Mine, page.Others = chat.Queue(tickets, payload.Id)
+		err = tpl.Execute(writer, page)
+		if err != nil {
+			logging.Errorf(request.Context(), "error while executing template %s %v", tpl.Name(), err)
+		}
+	}
+}
+
+func supportErrCode(err error) string {
+	switch {
+	case errors.Is(err, chat.ErrEmptySubject), errors.Is(err, chat.ErrLongSubject):
+		return "support.subject"
+	case errors.Is(err, chat.ErrEmptyMessage):
+		return "support.empty"
+	case errors.Is(err, chat.ErrLongMessage):
+		return "support.long"
+	case errors.Is(err, chat.ErrTicketClosed):
+		return "support.closed"
+	case errors.Is(err, chat.ErrTicketClaimed):
+		return "support.claimed"
+	case errors.Is(err, chat.ErrNotAssigned), errors.Is(err, chat.ErrForbidden):
+		return "support.assigned"
+	default:
+		return "support.failed"
+	}
+}
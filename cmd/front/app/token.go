@@ -1,8 +1,31 @@
 package app
 
+import (
+	"context"
+	jwtmux "github.com/jafarsirojov/bank-front/pkg/mux/middleware/jwt"
+)
+
+// RoleAgent is given by auth service to bank staff answering support chat
+const RoleAgent = "agent"
+
 type Payload struct {
 	Id    int    `json:"id"`
-	Exp   int64    `json:"exp"`
-	Phone int `json:"phone"`
+	Exp   int64  `json:"exp"`
+	Phone int    `json:"phone"`
+	Role  string `json:"role,omitempty"`
+}
+
+// IsAgent tells if user works with support queue, admin is agent too
+func (p *Payload) IsAgent() bool {
+	return p.Id == 0 || p.Role == RoleAgent
 }
 
+// UserID is written to access log
+func (p *Payload) UserID() int {
+	return p.Id
+}
+
+func payloadFromContext(ctx context.Context) (*Payload, bool) {
+	payload, ok := jwtmux.FromContext(ctx).(*Payload)
+	return payload, ok
+}
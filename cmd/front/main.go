@@ -1,16 +1,36 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"github.com/jafarsirojov/bank-front/cmd/front/app"
+	"github.com/jafarsirojov/bank-front/pkg/core/attachments"
+	"github.com/jafarsirojov/bank-front/pkg/core/audit"
 	"github.com/jafarsirojov/bank-front/pkg/core/auth"
+	"github.com/jafarsirojov/bank-front/pkg/core/beneficiaries"
+	"github.com/jafarsirojov/bank-front/pkg/core/blob"
 	"github.com/jafarsirojov/bank-front/pkg/core/cards"
 	"github.com/jafarsirojov/bank-front/pkg/core/chat"
+	"github.com/jafarsirojov/bank-front/pkg/core/confirm"
+	"github.com/jafarsirojov/bank-front/pkg/core/devices"
+	"github.com/jafarsirojov/bank-front/pkg/core/fx"
 	"github.com/jafarsirojov/bank-front/pkg/core/history"
+	"github.com/jafarsirojov/bank-front/pkg/core/limits"
+	"github.com/jafarsirojov/bank-front/pkg/core/notifications"
+	"github.com/jafarsirojov/bank-front/pkg/core/paymentrequests"
+	"github.com/jafarsirojov/bank-front/pkg/core/payments"
+	"github.com/jafarsirojov/bank-front/pkg/core/schedules"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
 	"github.com/jafarsirojov/bank-front/pkg/jwt"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
 	"github.com/jafarsirojov/bank-front/pkg/mux"
+	"github.com/jafarsirojov/bank-front/pkg/mux/middleware/requestid"
+	"github.com/jafarsirojov/bank-front/pkg/notify"
+	"log"
 	"net"
 	"net/http"
+	"os"
+	"time"
 )
 
 var (
@@ -20,25 +40,142 @@ var (
 	cardsUrl   = flag.String("cardsUrl", "", "Cards Service URL")
 	historyUrl = flag.String("historyUrl", "", "Transfer Service URL")
 	chatUrl    = flag.String("chatUrl", "", "Chat Service URL")
+	paymentUrl = flag.String("paymentsUrl", "", "Payments Service URL (cards service if empty)")
+	ratesFile  = flag.String("ratesFile", "configs/rates.json", "Static FX rates file")
+	fxFeeBps   = flag.Int64("fxFeeBps", 150, "FX conversion fee in basis points")
+	quoteTTL   = flag.Duration("quoteTTL", 60*time.Second, "How long FX quote is locked")
+	confirmTTL = flag.Duration("confirmTTL", 5*time.Minute, "How long transfer confirmation is valid")
+	dataDir    = flag.String("dataDir", "data", "Directory for data kept by front (empty - memory only)")
+	schedTick  = flag.Duration("schedulerInterval", time.Minute, "How often scheduled transfers are checked")
+	smtpAddr   = flag.String("smtpAddr", "", "SMTP server for email notifications, e.g. localhost:1025 (empty - email is off)")
+	smtpFrom   = flag.String("smtpFrom", "noreply@jbank.local", "Sender of email notifications")
+	smtpUser   = flag.String("smtpUser", "", "SMTP user, password is taken from SMTP_PASSWORD")
+	smsUrl     = flag.String("smsUrl", "", "SMS provider URL (empty - sms is off)")
+	smsFrom    = flag.String("smsFrom", "JBank", "Sender name of sms")
+	notifyOut  = flag.String("notifyOut", "", "Write email and sms to file instead of sending, - for stdout (development)")
+	notifyTpl  = flag.String("notifyTemplates", "configs/notify", "Directory with templates of email and sms")
+	publicUrl  = flag.String("publicUrl", "http://localhost:9012", "Address of front for links in notifications")
+	logFormat  = flag.String("logFormat", "logfmt", "Log format: logfmt or json")
+	logLevel   = flag.String("logLevel", "info", "Minimal log level: debug, info, warn or error")
 )
 
 //-host 0.0.0.0 -port 9012 -authUrl "http://localhost:9011" -cardsUrl "http://localhost:9019" -historyUrl "http://localhost:9010" -chatUrl "http://localhost:9013"
 
 func main() {
 	flag.Parse()
+	setupLogging()
 	addr := net.JoinHostPort(*host, *port)
 	secret := jwt.Secret("top secret")
-	start(addr, secret, auth.Url(*authUrl), cards.Url(*cardsUrl), history.Url(*historyUrl), chat.Url(*chatUrl))
+	ratesSvc, err := fx.NewStaticProvider(*ratesFile)
+	if err != nil {
+		panic(err)
+	}
+	if *paymentUrl == "" {
+		*paymentUrl = *cardsUrl
+	}
+	start(addr, secret, auth.Url(*authUrl), cards.Url(*cardsUrl), history.Url(*historyUrl), chat.Url(*chatUrl), payments.Url(*paymentUrl), ratesSvc, *dataDir, senders())
 }
 
-func start(addr string, secret jwt.Secret, authURL auth.Url, cardsURL cards.Url, historyURL history.Url, chatURL chat.Url) {
+// setupLogging makes records of standard log package structured too
+func setupLogging() {
+	format, err := logging.ParseFormat(*logFormat)
+	if err != nil {
+		panic(err)
+	}
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		panic(err)
+	}
+	logger := logging.New(os.Stderr, format, level)
+	logging.SetDefault(logger)
+	log.SetFlags(0)
+	log.SetOutput(logging.StdWriter(logger, logging.LevelInfo))
+}
+
+// senders returns outbound channels which are configured, in development all of them are written to file
+func senders() map[string]notify.Sender {
+	result := make(map[string]notify.Sender)
+	switch {
+	case *notifyOut == "-":
+		result[notifications.ChannelEmail] = notify.NewConsole(notifications.ChannelEmail)
+		result[notifications.ChannelSMS] = notify.NewConsole(notifications.ChannelSMS)
+	case *notifyOut != "":
+		result[notifications.ChannelEmail] = notify.NewFile(notifications.ChannelEmail, *notifyOut)
+		result[notifications.ChannelSMS] = notify.NewFile(notifications.ChannelSMS, *notifyOut)
+	default:
+		if *smtpAddr != "" {
+			result[notifications.ChannelEmail] = notify.NewSMTP(*smtpAddr, *smtpFrom, *smtpUser, os.Getenv("SMTP_PASSWORD"))
+		}
+		if *smsUrl != "" {
+			result[notifications.ChannelSMS] = notify.NewSMS(*smsUrl, os.Getenv("SMS_TOKEN"), *smsFrom)
+		}
+	}
+	return result
+}
+
+func start(addr string, secret jwt.Secret, authURL auth.Url, cardsURL cards.Url, historyURL history.Url, chatURL chat.Url, paymentsURL payments.Url, ratesSvc fx.Provider, dataDir string, senders map[string]notify.Sender) {
 	exactMux := mux.NewExactMux()
-	authSvc := auth.NewClient(authURL)
-	cardsSvc := cards.NewCard(cardsURL)
-	historySvc := history.NewHistory(historyURL)
-	chatSvc := chat.NewChat(chatURL)
-	server := app.NewServer(exactMux, secret, authSvc, cardsSvc, historySvc, chatSvc)
+	// request id goes to upstream services, sms provider isn't ours and gets plain client
+	upstream := &http.Client{Transport: logging.Transport{Base: http.DefaultTransport}}
+	authSvc := auth.NewClient(authURL, upstream)
+	cardsSvc := cards.NewCard(cardsURL, upstream)
+	historySvc := history.NewHistory(historyURL, upstream)
+	chatSvc := chat.NewChat(chatURL, upstream, secret)
+	quoter := fx.NewQuoter(ratesSvc, *fxFeeBps, *quoteTTL)
+	confirmer := confirm.NewConfirmer(secret, *confirmTTL)
+	beneficiariesSvc, err := beneficiaries.NewStore(storage.Dir(dataDir, "beneficiaries.json"))
+	if err != nil {
+		panic(err)
+	}
+	schedulesSvc, err := schedules.NewStore(storage.Dir(dataDir, "schedules.json"))
+	if err != nil {
+		panic(err)
+	}
+	paymentsSvc := payments.NewPayments(paymentsURL, upstream)
+	receiptsSvc, err := payments.NewReceipts(storage.LinesDir(dataDir, "receipts.jsonl"))
+	if err != nil {
+		panic(err)
+	}
+	limitsSvc, err := limits.NewStore(storage.Dir(dataDir, "limits.json"))
+	if err != nil {
+		panic(err)
+	}
+	auditLog := audit.Dir(dataDir, "audit.log")
+	requestsSvc, err := paymentrequests.NewStore(storage.Dir(dataDir, "requests.json"))
+	if err != nil {
+		panic(err)
+	}
+	attachmentsSvc, err := attachments.NewStore(storage.Dir(dataDir, "attachments.json"), blob.Dir(dataDir, "attachments"))
+	if err != nil {
+		panic(err)
+	}
+	notificationsSvc, err := notifications.NewStore(storage.Dir(dataDir, "notifications.json"))
+	if err != nil {
+		panic(err)
+	}
+	outbox, err := notify.NewQueue(storage.Dir(dataDir, "outbox.json"), senders)
+	if err != nil {
+		panic(err)
+	}
+	templates, err := notify.LoadTemplates(*notifyTpl)
+	if err != nil {
+		panic(err)
+	}
+	var channels []notifications.Channel
+	for _, name := range outbox.Channels() {
+		channels = append(channels, notifications.NewOutbound(name, outbox, templates, *publicUrl))
+	}
+	notifier := notifications.NewCenter(notificationsSvc, channels...)
+	devicesSvc, err := devices.NewStore(storage.Dir(dataDir, "devices.json"))
+	if err != nil {
+		panic(err)
+	}
+	server := app.NewServer(exactMux, secret, authSvc, cardsSvc, historySvc, chatSvc, quoter, confirmer, beneficiariesSvc, schedulesSvc, paymentsSvc, receiptsSvc, limitsSvc, auditLog, requestsSvc, attachmentsSvc, notifier, devicesSvc)
 	server.Start()
 
-	panic(http.ListenAndServe(addr, server))
+	scheduler := schedules.NewScheduler(schedulesSvc, server, schedules.SystemClock{}, *schedTick)
+	go scheduler.Run(context.Background())
+	go outbox.Run(context.Background())
+
+	panic(http.ListenAndServe(addr, requestid.RequestID()(server.ServeHTTP)))
 }
@@ -0,0 +1,205 @@
+package analytics
+
+import (
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/history"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"sort"
+	"time"
+)
+
+// Flow is money came to and left cards of one currency during month
+type Flow struct {
+	Month time.Time
+	In    money.Money
+	Out   money.Money
+}
+
+func (f Flow) Net() money.Money {
+	return money.New(f.In.Amount-f.Out.Amount, f.In.Currency)
+}
+
+// Counterparty sums operations with one card, Total is without sign
+type Counterparty struct {
+	Number cards.Number
+	Total  money.Money
+	In     money.Money
+	Out    money.Money
+	Count  int
+}
+
+type Point struct {
+	At      time.Time
+	Balance money.Money
+}
+
+// Series is balance of card after each operation
+type Series struct {
+	Number   cards.Number
+	Currency money.Currency
+	Points   []Point
+}
+
+// Report is everything shown on analytics page, amounts of different currencies are never summed
+type Report struct {
+	Currencies     []money.Currency
+	Flows          map[money.Currency][]Flow
+	Counterparties []Counterparty
+	Balances       []Series
+}
+
+// Build aggregates operations, they must have currencies set (see history.SetCurrencies)
+func Build(operations []history.ModelOperationsLog, location *time.Location, top int) Report {
+	flows := Monthly(operations, location)
+	currencies := make([]money.Currency, 0, len(flows))
+	for currency := range flows {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i] < currencies[j]
+	})
+	return Report{
+		Currencies:     currencies,
+		Flows:          flows,
+		Counterparties: TopCounterparties(operations, top),
+		Balances:       Balances(operations, location),
+	}
+}
+
+// Monthly returns flows per currency for every month from first to last operation,
+// months without operations are kept with zero amounts so charts have no gaps
+func Monthly(operations []history.ModelOperationsLog, location *time.Location) map[money.Currency][]Flow {
+	type key struct {
+		currency money.Currency
+		month    time.Time
+	}
+	sums := make(map[key]*Flow)
+	first := make(map[money.Currency]time.Time)
+	last := make(map[money.Currency]time.Time)
+	for _, operation := range operations {
+		currency := operation.Count.Currency
+		month := monthOf(operation.Moment(), location)
+		flow, ok := sums[key{currency, month}]
+		if !ok {
+			flow = &Flow{Month: month, In: money.New(0, currency), Out: money.New(0, currency)}
+			sums[key{currency, month}] = flow
+		}
+		if operation.IsDebit() {
+			flow.Out.Amount += operation.Count.Amount
+		} else {
+			flow.In.Amount += operation.Count.Amount
+		}
+		if start, ok := first[currency]; !ok || month.Before(start) {
+			first[currency] = month
+		}
+		if end, ok := last[currency]; !ok || month.After(end) {
+			last[currency] = month
+		}
+	}
+
+	flows := make(map[money.Currency][]Flow, len(first))
+	for currency, start := range first {
+		for month := start; !month.After(last[currency]); month = month.AddDate(0, 1, 0) {
+			flow, ok := sums[key{currency, month}]
+			if !ok {
+				flow = &Flow{Month: month, In: money.New(0, currency), Out: money.New(0, currency)}
+			}
+			flows[currency] = append(flows[currency], *flow)
+		}
+	}
+	return flows
+}
+
+func monthOf(moment time.Time, location *time.Location) time.Time {
+	moment = moment.In(location)
+	return time.Date(moment.Year(), moment.Month(), 1, 0, 0, 0, 0, location)
+}
+
+// TopCounterparties returns at most n cards with biggest turnover, cards in
+// different currencies are compared by amount in minor units
+func TopCounterparties(operations []history.ModelOperationsLog, n int) []Counterparty {
+	type key struct {
+		number   cards.Number
+		currency money.Currency
+	}
+	sums := make(map[key]*Counterparty)
+	for _, operation := range operations {
+		if operation.RecipientSender == "" {
+			continue
+		}
+		currency := operation.Count.Currency
+		counterparty, ok := sums[key{operation.RecipientSender, currency}]
+		if !ok {
+			counterparty = &Counterparty{
+				Number: operation.RecipientSender,
+				Total:  money.New(0, currency),
+				In:     money.New(0, currency),
+				Out:    money.New(0, currency),
+			}
+			sums[key{operation.RecipientSender, currency}] = counterparty
+		}
+		counterparty.Total.Amount += operation.Count.Amount
+		if operation.IsDebit() {
+			counterparty.Out.Amount += operation.Count.Amount
+		} else {
+			counterparty.In.Amount += operation.Count.Amount
+		}
+		counterparty.Count++
+	}
+
+	counterparties := make([]Counterparty, 0, len(sums))
+	for _, counterparty := range sums {
+		counterparties = append(counterparties, *counterparty)
+	}
+	sort.Slice(counterparties, func(i, j int) bool {
+		a, b := counterparties[i], counterparties[j]
+		if a.Total.Amount != b.Total.Amount {
+			return a.Total.Amount > b.Total.Amount
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Number < b.Number
+	})
+	if n > 0 && len(counterparties) > n {
+		counterparties = counterparties[:n]
+	}
+	return counterparties
+}
+
+// Balances returns series for every card ordered by number, first point is balance before first operation
+func Balances(operations []history.ModelOperationsLog, location *time.Location) []Series {
+	sorted := make([]history.ModelOperationsLog, len(operations))
+	copy(sorted, operations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Time != sorted[j].Time {
+			return sorted[i].Time < sorted[j].Time
+		}
+		return sorted[i].Id < sorted[j].Id
+	})
+
+	byNumber := make(map[cards.Number]*Series)
+	numbers := make([]cards.Number, 0)
+	for _, operation := range sorted {
+		series, ok := byNumber[operation.Number]
+		if !ok {
+			series = &Series{
+				Number:   operation.Number,
+				Currency: operation.BalanceNew.Currency,
+				Points:   []Point{{At: operation.Moment().In(location), Balance: operation.BalanceOld}},
+			}
+			byNumber[operation.Number] = series
+			numbers = append(numbers, operation.Number)
+		}
+		series.Points = append(series.Points, Point{At: operation.Moment().In(location), Balance: operation.BalanceNew})
+	}
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+
+	balances := make([]Series, 0, len(numbers))
+	for _, number := range numbers {
+		balances = append(balances, *byNumber[number])
+	}
+	return balances
+}
@@ -0,0 +1,229 @@
+package analytics
+
+import (
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/history"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"testing"
+	"time"
+)
+
+const (
+	cardTJS cards.Number = "4111111111111111"
+	cardUSD cards.Number = "5555555555554444"
+	friend  cards.Number = "2200000000000004"
+	shop    cards.Number = "6011000990139424"
+)
+
+// operation makes history record with currency set, debit when amount is negative
+func operation(id int, number cards.Number, counterparty cards.Number, amount int64, currency money.Currency, balance int64, at time.Time) history.ModelOperationsLog {
+	before := balance - amount
+	if amount < 0 {
+		amount = -amount
+	}
+	return history.ModelOperationsLog{
+		Id:              id,
+		Number:          number,
+		RecipientSender: counterparty,
+		Count:           money.New(amount, currency),
+		BalanceOld:      money.New(before, currency),
+		BalanceNew:      money.New(balance, currency),
+		Time:            at.Unix(),
+	}
+}
+
+func at(month time.Month, day int, hour int) time.Time {
+	return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestMonthly(t *testing.T) {
+	dushanbe := time.FixedZone("TJT", 5*60*60)
+	tests := []struct {
+		name       string
+		operations []history.ModelOperationsLog
+		location   *time.Location
+		want       map[money.Currency][]Flow
+	}{
+		{
+			name:     "empty",
+			location: time.UTC,
+			want:     map[money.Currency][]Flow{},
+		},
+		{
+			name: "in and out of one month",
+			operations: []history.ModelOperationsLog{
+				operation(1, cardTJS, friend, 5000, money.TJS, 15000, at(3, 2, 10)),
+				operation(2, cardTJS, shop, -2000, money.TJS, 13000, at(3, 20, 10)),
+				operation(3, cardTJS, shop, -500, money.TJS, 12500, at(3, 31, 10)),
+			},
+			location: time.UTC,
+			want: map[money.Currency][]Flow{
+				money.TJS: {
+					{Month: at(3, 1, 0), In: money.New(5000, money.TJS), Out: money.New(2500, money.TJS)},
+				},
+			},
+		},
+		{
+			name: "months without operations are zero",
+			operations: []history.ModelOperationsLog{
+				operation(1, cardTJS, friend, 5000, money.TJS, 15000, at(1, 15, 10)),
+				operation(2, cardTJS, shop, -1000, money.TJS, 14000, at(4, 15, 10)),
+			},
+			location: time.UTC,
+			want: map[money.Currency][]Flow{
+				money.TJS: {
+					{Month: at(1, 1, 0), In: money.New(5000, money.TJS), Out: money.New(0, money.TJS)},
+					{Month: at(2, 1, 0), In: money.New(0, money.TJS), Out: money.New(0, money.TJS)},
+					{Month: at(3, 1, 0), In: money.New(0, money.TJS), Out: money.New(0, money.TJS)},
+					{Month: at(4, 1, 0), In: money.New(0, money.TJS), Out: money.New(1000, money.TJS)},
+				},
+			},
+		},
+		{
+			name: "currencies are not summed",
+			operations: []history.ModelOperationsLog{
+				operation(1, cardTJS, friend, 5000, money.TJS, 15000, at(3, 2, 10)),
+				operation(2, cardUSD, shop, -300, money.USD, 700, at(3, 3, 10)),
+				operation(3, cardUSD, friend, 100, money.USD, 800, at(5, 3, 10)),
+			},
+			location: time.UTC,
+			want: map[money.Currency][]Flow{
+				money.TJS: {
+					{Month: at(3, 1, 0), In: money.New(5000, money.TJS), Out: money.New(0, money.TJS)},
+				},
+				money.USD: {
+					{Month: at(3, 1, 0), In: money.New(0, money.USD), Out: money.New(300, money.USD)},
+					{Month: at(4, 1, 0), In: money.New(0, money.USD), Out: money.New(0, money.USD)},
+					{Month: at(5, 1, 0), In: money.New(100, money.USD), Out: money.New(0, money.USD)},
+				},
+			},
+		},
+		{
+			name: "month is taken in user time zone",
+			operations: []history.ModelOperationsLog{
+				operation(1, cardTJS, shop, -700, money.TJS, 9300, at(3, 31, 20)),
+			},
+			location: dushanbe,
+			want: map[money.Currency][]Flow{
+				money.TJS: {
+					{Month: time.Date(2026, 4, 1, 0, 0, 0, 0, dushanbe), In: money.New(0, money.TJS), Out: money.New(700, money.TJS)},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		got := Monthly(test.operations, test.location)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %d currencies, want %d", test.name, len(got), len(test.want))
+			continue
+		}
+		for currency, want := range test.want {
+			flows := got[currency]
+			if len(flows) != len(want) {
+				t.Errorf("%s: %s has %d months, want %d", test.name, currency, len(flows), len(want))
+				continue
+			}
+			for i := range want {
+				if !flows[i].Month.Equal(want[i].Month) || flows[i].In != want[i].In || flows[i].Out != want[i].Out {
+					t.Errorf("%s: %s month %d = %+v, want %+v", test.name, currency, i, flows[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestFlowNet(t *testing.T) {
+	flow := Flow{In: money.New(1000, money.USD), Out: money.New(2500, money.USD)}
+	if flow.Net() != money.New(-1500, money.USD) {
+		t.Errorf("Net() = %v", flow.Net())
+	}
+}
+
+func TestTopCounterparties(t *testing.T) {
+	operations := []history.ModelOperationsLog{
+		operation(1, cardTJS, friend, 5000, money.TJS, 15000, at(3, 2, 10)),
+		operation(2, cardTJS, friend, -1000, money.TJS, 14000, at(3, 3, 10)),
+		operation(3, cardTJS, shop, -3000, money.TJS, 11000, at(3, 4, 10)),
+		operation(4, cardUSD, friend, -200, money.USD, 800, at(3, 5, 10)),
+		operation(5, cardTJS, "", -100, money.TJS, 10900, at(3, 6, 10)),
+	}
+	tests := []struct {
+		n    int
+		want []Counterparty
+	}{
+		{
+			n: 0,
+			want: []Counterparty{
+				{Number: friend, Total: money.New(6000, money.TJS), In: money.New(5000, money.TJS), Out: money.New(1000, money.TJS), Count: 2},
+				{Number: shop, Total: money.New(3000, money.TJS), In: money.New(0, money.TJS), Out: money.New(3000, money.TJS), Count: 1},
+				{Number: friend, Total: money.New(200, money.USD), In: money.New(0, money.USD), Out: money.New(200, money.USD), Count: 1},
+			},
+		},
+		{
+			n: 1,
+			want: []Counterparty{
+				{Number: friend, Total: money.New(6000, money.TJS), In: money.New(5000, money.TJS), Out: money.New(1000, money.TJS), Count: 2},
+			},
+		},
+	}
+	for _, test := range tests {
+		got := TopCounterparties(operations, test.n)
+		if len(got) != len(test.want) {
+			t.Errorf("n=%d: got %d counterparties, want %d", test.n, len(got), len(test.want))
+			continue
+		}
+		for i := range test.want {
+			if got[i] != test.want[i] {
+				t.Errorf("n=%d: counterparty %d = %+v, want %+v", test.n, i, got[i], test.want[i])
+			}
+		}
+	}
+}
+
+func TestBalances(t *testing.T) {
+	operations := []history.ModelOperationsLog{
+		operation(2, cardUSD, shop, -300, money.USD, 700, at(3, 3, 10)),
+		operation(3, cardTJS, shop, -2000, money.TJS, 13000, at(3, 20, 10)),
+		operation(1, cardTJS, friend, 5000, money.TJS, 15000, at(3, 2, 10)),
+	}
+	got := Balances(operations, time.UTC)
+	want := []Series{
+		{Number: cardTJS, Currency: money.TJS, Points: []Point{
+			{At: at(3, 2, 10), Balance: money.New(10000, money.TJS)},
+			{At: at(3, 2, 10), Balance: money.New(15000, money.TJS)},
+			{At: at(3, 20, 10), Balance: money.New(13000, money.TJS)},
+		}},
+		{Number: cardUSD, Currency: money.USD, Points: []Point{
+			{At: at(3, 3, 10), Balance: money.New(1000, money.USD)},
+			{At: at(3, 3, 10), Balance: money.New(700, money.USD)},
+		}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d series, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Number != want[i].Number || got[i].Currency != want[i].Currency || len(got[i].Points) != len(want[i].Points) {
+			t.Errorf("series %d = %+v, want %+v", i, got[i], want[i])
+			continue
+		}
+		for j := range want[i].Points {
+			if !got[i].Points[j].At.Equal(want[i].Points[j].At) || got[i].Points[j].Balance != want[i].Points[j].Balance {
+				t.Errorf("series %d point %d = %+v, want %+v", i, j, got[i].Points[j], want[i].Points[j])
+			}
+		}
+	}
+}
+
+func TestBuildCurrencies(t *testing.T) {
+	operations := []history.ModelOperationsLog{
+		operation(1, cardUSD, shop, -300, money.USD, 700, at(3, 3, 10)),
+		operation(2, cardTJS, friend, 5000, money.TJS, 15000, at(3, 2, 10)),
+	}
+	report := Build(operations, time.UTC, 10)
+	if len(report.Currencies) != 2 || report.Currencies[0] != money.TJS || report.Currencies[1] != money.USD {
+		t.Errorf("currencies = %v, want [TJS USD]", report.Currencies)
+	}
+	if len(report.Counterparties) != 2 || len(report.Balances) != 2 {
+		t.Errorf("report = %+v", report)
+	}
+}
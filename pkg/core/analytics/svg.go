@@ -0,0 +1,172 @@
+package analytics
+
+import (
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// charts are drawn on server, page doesn't need any chart library
+const (
+	chartWidth  = 640
+	chartHeight = 260
+	marginLeft  = 90
+	marginRight = 20
+	marginTop   = 20
+	marginBot   = 40
+
+	colorIn   = "#28a745"
+	colorOut  = "#dc3545"
+	colorLine = "#007bff"
+	colorGrid = "#dee2e6"
+	colorText = "#6c757d"
+)
+
+type svg struct {
+	builder strings.Builder
+}
+
+func newSVG(title string) *svg {
+	chart := &svg{}
+	fmt.Fprintf(&chart.builder,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="100%%" role="img" aria-label="%s" font-family="sans-serif" font-size="11">`,
+		chartWidth, chartHeight, html.EscapeString(title),
+	)
+	return chart
+}
+
+func (s *svg) rect(x, y, width, height float64, color, title string) {
+	fmt.Fprintf(&s.builder, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s</title></rect>`,
+		x, y, width, height, color, html.EscapeString(title))
+}
+
+func (s *svg) line(x1, y1, x2, y2 float64, color string) {
+	fmt.Fprintf(&s.builder, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`, x1, y1, x2, y2, color)
+}
+
+func (s *svg) text(x, y float64, anchor, value string) {
+	fmt.Fprintf(&s.builder, `<text x="%.1f" y="%.1f" text-anchor="%s" fill="%s">%s</text>`,
+		x, y, anchor, colorText, html.EscapeString(value))
+}
+
+func (s *svg) html() template.HTML {
+	s.builder.WriteString(`</svg>`)
+	// every value put into svg is escaped above
+	return template.HTML(s.builder.String())
+}
+
+// plot area
+const (
+	plotLeft   = float64(marginLeft)
+	plotRight  = float64(chartWidth - marginRight)
+	plotTop    = float64(marginTop)
+	plotBottom = float64(chartHeight - marginBot)
+)
+
+// FlowChart draws inflow and outflow bars for each month
+func FlowChart(flows []Flow) template.HTML {
+	chart := newSVG("Поступления и списания по месяцам")
+	if len(flows) == 0 {
+		chart.text(chartWidth/2, chartHeight/2, "middle", "Нет операций")
+		return chart.html()
+	}
+
+	var max int64
+	for _, flow := range flows {
+		if flow.In.Amount > max {
+			max = flow.In.Amount
+		}
+		if flow.Out.Amount > max {
+			max = flow.Out.Amount
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+	scale := func(amount int64) float64 {
+		return float64(amount) / float64(max) * (plotBottom - plotTop)
+	}
+
+	chart.line(plotLeft, plotTop, plotRight, plotTop, colorGrid)
+	chart.text(plotLeft-6, plotTop+4, "end", money.New(max, flows[0].In.Currency).String())
+	chart.line(plotLeft, plotBottom, plotRight, plotBottom, colorGrid)
+	chart.text(plotLeft-6, plotBottom+4, "end", "0")
+
+	slot := (plotRight - plotLeft) / float64(len(flows))
+	bar := slot * 0.35
+	// show every label only when they fit
+	every := 1 + len(flows)*45/int(plotRight-plotLeft)
+	for i, flow := range flows {
+		x := plotLeft + slot*float64(i) + slot*0.15
+		month := flow.Month.Format("01.2006")
+		chart.rect(x, plotBottom-scale(flow.In.Amount), bar, scale(flow.In.Amount), colorIn,
+			fmt.Sprintf("%s: поступления %s", month, flow.In))
+		chart.rect(x+bar, plotBottom-scale(flow.Out.Amount), bar, scale(flow.Out.Amount), colorOut,
+			fmt.Sprintf("%s: списания %s", month, flow.Out))
+		if i%every == 0 {
+			chart.text(x+bar, plotBottom+16, "middle", month)
+		}
+	}
+	return chart.html()
+}
+
+// BalanceChart draws balance of card as step line
+func BalanceChart(series Series) template.HTML {
+	chart := newSVG("Остаток по карте " + series.Number.String())
+	if len(series.Points) == 0 {
+		chart.text(chartWidth/2, chartHeight/2, "middle", "Нет операций")
+		return chart.html()
+	}
+
+	first, last := series.Points[0].At, series.Points[len(series.Points)-1].At
+	min, max := series.Points[0].Balance.Amount, series.Points[0].Balance.Amount
+	for _, point := range series.Points {
+		if point.Balance.Amount < min {
+			min = point.Balance.Amount
+		}
+		if point.Balance.Amount > max {
+			max = point.Balance.Amount
+		}
+	}
+	if min > 0 {
+		min = 0
+	}
+	if max == min {
+		max = min + 1
+	}
+	duration := last.Sub(first).Seconds()
+	x := func(i int) float64 {
+		if duration == 0 {
+			return plotLeft + (plotRight-plotLeft)*float64(i)/float64(len(series.Points))
+		}
+		return plotLeft + series.Points[i].At.Sub(first).Seconds()/duration*(plotRight-plotLeft)
+	}
+	y := func(amount int64) float64 {
+		return plotBottom - float64(amount-min)/float64(max-min)*(plotBottom-plotTop)
+	}
+
+	chart.line(plotLeft, plotTop, plotRight, plotTop, colorGrid)
+	chart.text(plotLeft-6, plotTop+4, "end", money.New(max, series.Currency).String())
+	chart.line(plotLeft, plotBottom, plotRight, plotBottom, colorGrid)
+	chart.text(plotLeft-6, plotBottom+4, "end", money.New(min, series.Currency).String())
+	chart.text(plotLeft, plotBottom+16, "start", first.Format("02.01.2006"))
+	chart.text(plotRight, plotBottom+16, "end", last.Format("02.01.2006"))
+
+	points := make([]string, 0, len(series.Points)*2)
+	for i, point := range series.Points {
+		if i > 0 {
+			// balance holds until next operation
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(series.Points[i-1].Balance.Amount)))
+		}
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(point.Balance.Amount)))
+	}
+	fmt.Fprintf(&chart.builder, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`,
+		colorLine, strings.Join(points, " "))
+	for i, point := range series.Points {
+		fmt.Fprintf(&chart.builder, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s: %s</title></circle>`,
+			x(i), y(point.Balance.Amount), colorLine, point.At.Format("02.01.2006 15:04"), html.EscapeString(point.Balance.String()))
+	}
+	return chart.html()
+}
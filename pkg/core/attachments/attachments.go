@@ -0,0 +1,170 @@
+package attachments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/blob"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrNotFound = errors.New("attachment not found")
+	ErrTooLarge = errors.New("attachment is too large")
+	ErrEmpty    = errors.New("attachment is empty")
+	ErrType     = errors.New("attachment type isn't allowed")
+)
+
+// MaxSize is limit of one file, request body may be a bit bigger because of form fields
+const MaxSize = 5 << 20
+
+const maxNameLength = 100
+
+// allowed types are detected from content, name and type sent by browser aren't trusted
+var allowed = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// Attachment is file sent in chat, only sender and recipient may get it
+type Attachment struct {
+	ID          string    `json:"id"`
+	OwnerID     int       `json:"owner_id"`
+	RecipientID int       `json:"recipient_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (a Attachment) VisibleTo(userID int) bool {
+	return a.OwnerID == userID || a.RecipientID == userID
+}
+
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
+
+// Sniff returns content type of data if it's allowed
+func Sniff(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", ErrEmpty
+	}
+	if len(data) > MaxSize {
+		return "", ErrTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	if _, ok := allowed[contentType]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrType, contentType)
+	}
+	return contentType, nil
+}
+
+// cleanName keeps base name without control characters, extension follows real type
+func cleanName(name string, contentType string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '\\' || r == '/' {
+			return -1
+		}
+		return r
+	}, filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if utf8.RuneCountInString(name) > maxNameLength {
+		name = string([]rune(name)[:maxNameLength])
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." {
+		name = "attachment"
+	}
+	return name + allowed[contentType]
+}
+
+type Store struct {
+	mutex sync.RWMutex
+	file  *storage.File
+	blobs blob.Store
+	items []Attachment
+}
+
+func NewStore(file *storage.File, blobs blob.Store) (*Store, error) {
+	store := &Store{file: file, blobs: blobs}
+	err := file.Load(&store.items)
+	if err != nil {
+		return nil, fmt.Errorf("can't load attachments: %w", err)
+	}
+	return store, nil
+}
+
+// Save checks file, strips metadata from images and keeps it for sender and recipient
+func (s *Store) Save(ctx context.Context, ownerID int, recipientID int, name string, data []byte, now time.Time) (Attachment, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return Attachment{}, err
+	}
+	data, err = StripMetadata(contentType, data)
+	if err != nil {
+		return Attachment{}, err
+	}
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("can't generate id: %w", err)
+	}
+	attachment := Attachment{
+		ID:          hex.EncodeToString(id),
+		OwnerID:     ownerID,
+		RecipientID: recipientID,
+		Name:        cleanName(name, contentType),
+		ContentType: contentType,
+		Size:        len(data),
+		CreatedAt:   now,
+	}
+	err = s.blobs.Put(ctx, attachment.ID, data)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("can't store attachment: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items := append(s.items, attachment)
+	err = s.file.Save(items)
+	if err != nil {
+		_ = s.blobs.Delete(ctx, attachment.ID)
+		return Attachment{}, fmt.Errorf("can't save attachments: %w", err)
+	}
+	s.items = items
+	return attachment, nil
+}
+
+func (s *Store) Get(id string) (Attachment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, item := range s.items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return Attachment{}, ErrNotFound
+}
+
+// Open returns content of attachment, caller closes it
+func (s *Store) Open(ctx context.Context, attachment Attachment) (io.ReadCloser, error) {
+	content, err := s.blobs.Get(ctx, attachment.ID)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return content, err
+}
@@ -0,0 +1,114 @@
+package attachments
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrCorrupt = errors.New("corrupt image")
+
+// StripMetadata removes segments which may have camera, location or author of image.
+// Image data is copied byte by byte, so quality isn't lost as with decoding and encoding again.
+// Other content types are returned as is
+func StripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	default:
+		return data, nil
+	}
+}
+
+// JPEG markers with metadata: APP1 is Exif and XMP, APP13 is IPTC, COM is comment.
+// APP0 (JFIF), APP2 (ICC profile) and APP14 (Adobe) are kept, colors depend on them.
+// Orientation from Exif is lost, like on most sites which strip Exif
+const (
+	markerSOS  = 0xDA
+	markerAPP1 = 0xE1
+	markerAPPD = 0xED
+	markerCOM  = 0xFE
+)
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrCorrupt
+	}
+	result := bytes.NewBuffer(make([]byte, 0, len(data)))
+	result.Write(data[:2])
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, ErrCorrupt
+		}
+		// markers may be padded with 0xFF
+		for i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+		}
+		if i+1 >= len(data) {
+			return nil, ErrCorrupt
+		}
+		marker := data[i+1]
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			result.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return nil, ErrCorrupt
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:i+4]))
+		if end > len(data) || end < i+4 {
+			return nil, ErrCorrupt
+		}
+		if marker == markerSOS {
+			// scan data goes up to end of image, nothing to strip there
+			result.Write(data[i:])
+			return result.Bytes(), nil
+		}
+		if marker != markerAPP1 && marker != markerAPPD && marker != markerCOM {
+			result.Write(data[i:end])
+		}
+		i = end
+	}
+	return nil, ErrCorrupt
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// PNG text chunks may have author and software, eXIf is same Exif as in JPEG
+var pngMetadata = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrCorrupt
+	}
+	result := bytes.NewBuffer(make([]byte, 0, len(data)))
+	result.Write(pngSignature)
+	i := len(pngSignature)
+	for i+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		kind := string(data[i+4 : i+8])
+		// length, type, data and crc
+		end := i + 12 + length
+		if length < 0 || end > len(data) || end < i {
+			return nil, ErrCorrupt
+		}
+		if !pngMetadata[kind] {
+			result.Write(data[i:end])
+		}
+		i = end
+		if kind == "IEND" {
+			return result.Bytes(), nil
+		}
+	}
+	return nil, ErrCorrupt
+}
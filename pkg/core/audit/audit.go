@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is security relevant event, e.g. attempt to use card of another user
+type Entry struct {
+	Time   time.Time `json:"time"`
+	UserID int       `json:"user_id"`
+	Action string    `json:"action"`
+	CardID string    `json:"card_id,omitempty"`
+	Reason string    `json:"reason"`
+}
+
+// Log appends entries as json lines, empty path means entries only go to application log
+type Log struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Dir returns Log for file name inside dir, or application log only if dir is empty
+func Dir(dir string, name string) *Log {
+	if dir == "" {
+		return NewLog("")
+	}
+	return NewLog(filepath.Join(dir, name))
+}
+
+func (l *Log) Record(ctx context.Context, entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	logging.Warnf(ctx, "audit: user %d %s card %s: %s", entry.UserID, entry.Action, entry.CardID, entry.Reason)
+	if l.path == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("can't encode audit entry: %w", err)
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	err = os.MkdirAll(filepath.Dir(l.path), 0700)
+	if err != nil {
+		return fmt.Errorf("can't create audit log dir: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("can't open audit log: %w", err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("can't write audit log: %w", err)
+	}
+	return file.Close()
+}
@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -43,16 +42,19 @@ func (e *ErrorResponse) Unwrap() error {
 }
 
 type Client struct {
-	url Url
+	url    Url
+	client *http.Client
 }
 
-func NewClient(url Url) *Client {
-	return &Client{url: url}
+// NewClient calls service with client, its transport forwards request id
+func NewClient(url Url, client *http.Client) *Client {
+	return &Client{url: url, client: client}
 }
 
 func (c *Client) Login(ctx context.Context, login string, password string) (token string, err error) {
 	// add timeout to context
-	ctx, _ = context.WithTimeout(ctx, time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
 
 	requestData := TokenRequest{
 		Username: login,
@@ -74,7 +76,7 @@ func (c *Client) Login(ctx context.Context, login string, password string) (toke
 	request.Header.Set("Content-Type", "application/json")
 	// in other request
 	// request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	response, err := http.DefaultClient.Do(request)
+	response, err := c.client.Do(request)
 	if err != nil {
 		// context.Canceled
 		// context.DeadlineExceeded
@@ -108,12 +110,12 @@ func (c *Client) Login(ctx context.Context, login string, password string) (toke
 }
 
 func (c *Client) Register(ctx context.Context, name string, login string, password string, phone string) (err error) {
-	ctx, _ = context.WithTimeout(ctx, 55*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
 
 	phoneInt, err := strconv.Atoi(phone)
 	if err != nil {
-		log.Printf("can't atoi: %s", err)
-		return
+		return fmt.Errorf("can't parse phone %q: %w", phone, err)
 	}
 
 	requestData := UserTDO{
@@ -134,29 +136,24 @@ func (c *Client) Register(ctx context.Context, name string, login string, passwo
 		bytes.NewBuffer(requestBody),
 	)
 	if err != nil {
-		log.Println("Can't http.NewRequestWithContext, client.go 137")
 		return fmt.Errorf("can't create request: %w", err)
 	}
 	request.Header.Set("Content-Type", "application/json")
-	response, err := http.DefaultClient.Do(request)
+	response, err := c.client.Do(request)
 	if err != nil {
-		log.Println("Can't http.DefaultClient.Do, client.go 143")
 		return fmt.Errorf("can't send request: %w", err)
 	}
 	defer response.Body.Close()
 
 	switch response.StatusCode {
 	case 200:
-		log.Print("register 200 ok")
 		return nil
 	case 400:
-		log.Print("can't bad request 400")
 		return fmt.Errorf("can't bad request 400: %w", err)
 	case 500:
-		log.Print("can't server internal error 500")
 		return fmt.Errorf("can't server internal error 500: %w", err)
 	default:
-		return fmt.Errorf("can't register: %s", response.StatusCode)
+		return fmt.Errorf("can't register: %d", response.StatusCode)
 	}
 }
 
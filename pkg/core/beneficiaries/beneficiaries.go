@@ -0,0 +1,180 @@
+package beneficiaries
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("beneficiary not found")
+var ErrEmptyNickname = errors.New("nickname can't be empty")
+
+// Beneficiary is saved transfer recipient, cards service has no address book so we keep it here
+type Beneficiary struct {
+	Id        int64        `json:"id"`
+	OwnerID   int          `json:"owner_id"`
+	Nickname  string       `json:"nickname"`
+	Number    cards.Number `json:"number"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type Store struct {
+	mutex sync.RWMutex
+	file  *storage.File
+	data  storeData
+}
+
+type storeData struct {
+	NextID int64         `json:"next_id"`
+	Items  []Beneficiary `json:"items"`
+}
+
+func NewStore(file *storage.File) (*Store, error) {
+	store := &Store{file: file}
+	err := file.Load(&store.data)
+	if err != nil {
+		return nil, fmt.Errorf("can't load beneficiaries: %w", err)
+	}
+	return store, nil
+}
+
+func (s *Store) List(ownerID int) []Beneficiary {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]Beneficiary, 0)
+	for _, item := range s.data.Items {
+		if item.OwnerID == ownerID {
+			result = append(result, item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Nickname) < strings.ToLower(result[j].Nickname)
+	})
+	return result
+}
+
+func (s *Store) Get(ownerID int, id int64) (Beneficiary, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	index := s.indexOf(ownerID, id)
+	if index < 0 {
+		return Beneficiary{}, ErrNotFound
+	}
+	return s.data.Items[index], nil
+}
+
+func (s *Store) FindByNumber(ownerID int, number cards.Number) (Beneficiary, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, item := range s.data.Items {
+		if item.OwnerID == ownerID && item.Number == number {
+			return item, true
+		}
+	}
+	return Beneficiary{}, false
+}
+
+// Add saves recipient, if the number is already saved only nickname is changed
+func (s *Store) Add(ownerID int, nickname string, number cards.Number) (Beneficiary, error) {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" {
+		return Beneficiary{}, ErrEmptyNickname
+	}
+	number, err := cards.ParseNumber(string(number))
+	if err != nil {
+		return Beneficiary{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data := s.copyData()
+	for i, item := range data.Items {
+		if item.OwnerID == ownerID && item.Number == number {
+			data.Items[i].Nickname = nickname
+			err = s.save(data)
+			if err != nil {
+				return Beneficiary{}, err
+			}
+			return data.Items[i], nil
+		}
+	}
+
+	data.NextID++
+	item := Beneficiary{
+		Id:        data.NextID,
+		OwnerID:   ownerID,
+		Nickname:  nickname,
+		Number:    number,
+		CreatedAt: time.Now(),
+	}
+	data.Items = append(data.Items, item)
+	err = s.save(data)
+	if err != nil {
+		return Beneficiary{}, err
+	}
+	return item, nil
+}
+
+func (s *Store) Rename(ownerID int, id int64, nickname string) error {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" {
+		return ErrEmptyNickname
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index := s.indexOf(ownerID, id)
+	if index < 0 {
+		return ErrNotFound
+	}
+	data := s.copyData()
+	data.Items[index].Nickname = nickname
+	return s.save(data)
+}
+
+func (s *Store) Delete(ownerID int, id int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index := s.indexOf(ownerID, id)
+	if index < 0 {
+		return ErrNotFound
+	}
+	data := s.copyData()
+	data.Items = append(data.Items[:index], data.Items[index+1:]...)
+	return s.save(data)
+}
+
+func (s *Store) indexOf(ownerID int, id int64) int {
+	for i, item := range s.data.Items {
+		if item.OwnerID == ownerID && item.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyData returns data which can be changed without touching store
+func (s *Store) copyData() storeData {
+	return storeData{NextID: s.data.NextID, Items: append([]Beneficiary(nil), s.data.Items...)}
+}
+
+// save writes data and only then swaps it in, so memory doesn't differ from file on error
+func (s *Store) save(data storeData) error {
+	err := s.file.Save(data)
+	if err != nil {
+		return fmt.Errorf("can't save beneficiaries: %w", err)
+	}
+	s.data = data
+	return nil
+}
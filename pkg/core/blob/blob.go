@@ -0,0 +1,141 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+var (
+	ErrNotFound = errors.New("blob not found")
+	ErrBadKey   = errors.New("bad blob key")
+)
+
+// Store keeps file contents by key, keys are generated by callers and never come from users
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// key is used as file name, so only simple names are allowed
+var keyPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,128}$`)
+
+func checkKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrBadKey, key)
+	}
+	return nil
+}
+
+// Dir returns file system store inside dir, or memory store if dir is empty
+func Dir(dir string, name string) Store {
+	if dir == "" {
+		return NewMemory()
+	}
+	return NewFS(filepath.Join(dir, name))
+}
+
+// FS keeps every blob in own file inside root
+type FS struct {
+	root string
+}
+
+func NewFS(root string) *FS {
+	return &FS{root: root}
+}
+
+// Put writes data to temp file and renames it, so blob is never half written
+func (f *FS) Put(ctx context.Context, key string, data []byte) error {
+	err := checkKey(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(f.root, 0700)
+	if err != nil {
+		return fmt.Errorf("can't create dir %s: %w", f.root, err)
+	}
+	path := filepath.Join(f.root, key)
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("can't write %s: %w", tmp, err)
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("can't rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+func (f *FS) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	err := checkKey(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filepath.Join(f.root, key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't open blob %s: %w", key, err)
+	}
+	return file, nil
+}
+
+func (f *FS) Delete(ctx context.Context, key string) error {
+	err := checkKey(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(f.root, key))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("can't remove blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// Memory is store for development without data dir, blobs are lost on restart
+type Memory struct {
+	mutex sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{blobs: make(map[string][]byte)}
+}
+
+func (m *Memory) Put(ctx context.Context, key string, data []byte) error {
+	err := checkKey(key)
+	if err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.blobs[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.blobs, key)
+	return nil
+}
@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/fx"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -17,26 +19,44 @@ import (
 type Url string
 
 type Cards struct {
-	Id      int    `json:"id"`
-	Number  string `json:"number"`
-	Name    string `json:"name"`
-	Balance int    `json:"balance"`
-	OwnerID int    `json:"owner_id"`
+	Id       int            `json:"id"`
+	Number   Number         `json:"number"`
+	Name     string         `json:"name"`
+	Balance  money.Money    `json:"balance"`
+	Currency money.Currency `json:"currency"`
+	OwnerID  int            `json:"owner_id"`
+	Blocked  bool           `json:"blocked"`
 }
+
+// cards created before multi-currency support have no currency
+func (c *Cards) normalize() {
+	if c.Currency == "" {
+		c.Currency = money.DefaultCurrency
+	}
+	c.Balance = c.Balance.WithCurrency(c.Currency)
+}
+
 type ModelTransferMoneyCardToCard struct {
-	IdCardSender        int    `json:"id_card_sender"`
-	NumberCardRecipient string `json:"number_card_recipient"`
-	Count               int    `json:"count"`
+	IdCardSender        int          `json:"id_card_sender"`
+	NumberCardRecipient Number       `json:"number_card_recipient"`
+	Count               money.Money  `json:"count"`
+	Currency            string       `json:"currency"`
+	CountRecipient      *money.Money `json:"count_recipient,omitempty"`
+	CurrencyRecipient   string       `json:"currency_recipient,omitempty"`
+	Fee                 *money.Money `json:"fee,omitempty"`
+	Rate                string       `json:"rate,omitempty"`
+	QuoteID             string       `json:"quote_id,omitempty"`
 }
 
 type ModelBlockCard struct {
 	Id     int    `json:"id"`
-	Number string `json:"number"`
+	Number Number `json:"number"`
 }
 
 // errors are part API
 var ErrUnknown = errors.New("unknown error")
 var ErrResponse = errors.New("response error")
+var ErrCardNotFound = errors.New("card not found")
 
 type ErrorResponse struct {
 	Errors []string `json:"errors"`
@@ -52,15 +72,18 @@ func (e *ErrorResponse) Unwrap() error {
 }
 
 type Card struct {
-	url Url
+	url    Url
+	client *http.Client
 }
 
-func NewCard(url Url) *Card {
-	return &Card{url: url}
+// NewCard calls service with client, its transport forwards request id
+func NewCard(url Url, client *http.Client) *Card {
+	return &Card{url: url, client: client}
 }
 
 func (c *Card) AllCards(ctx context.Context, token string) (model []Cards, err error) {
-	ctx, _ = context.WithTimeout(ctx, 55*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
 	request, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodGet,
@@ -71,17 +94,12 @@ func (c *Card) AllCards(ctx context.Context, token string) (model []Cards, err e
 		return nil, fmt.Errorf("can't create request: %w", err)
 	}
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	response, err := http.DefaultClient.Do(request)
+	response, err := c.client.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("can't send request: %w", err)
 	}
 
-	defer func() {
-		err = response.Body.Close()
-		if err != nil {
-			log.Fatalf("can't close response body: %d", err)
-		}
-	}()
+	defer response.Body.Close()
 	err = ReadJSONBody2(response, &model)
 	if err != nil {
 		return nil, fmt.Errorf("can't parse response: %w", err)
@@ -89,6 +107,9 @@ func (c *Card) AllCards(ctx context.Context, token string) (model []Cards, err e
 
 	switch response.StatusCode {
 	case 200:
+		for i := range model {
+			model[i].normalize()
+		}
 		return model, nil
 	case 400:
 
@@ -98,6 +119,80 @@ func (c *Card) AllCards(ctx context.Context, token string) (model []Cards, err e
 	}
 }
 
+func (c *Card) CardByNumber(ctx context.Context, number Number, token string) (model Cards, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("%s/api/cards/number/%s", c.url, url.PathEscape(string(number))),
+		bytes.NewBuffer(nil),
+	)
+	if err != nil {
+		return Cards{}, fmt.Errorf("can't create request: %w", err)
+	}
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	response, err := c.client.Do(request)
+	if err != nil {
+		return Cards{}, fmt.Errorf("can't send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case 200:
+		err = ReadJSONBody2(response, &model)
+		if err != nil {
+			return Cards{}, fmt.Errorf("can't parse response: %w", err)
+		}
+		model.normalize()
+		return model, nil
+	case 404:
+		return Cards{}, ErrCardNotFound
+	case 400:
+		return Cards{}, ErrResponse
+	default:
+		return Cards{}, ErrUnknown
+	}
+}
+
+// ReceivingCard returns card of user where money in currency should go, cards service chooses
+// card in same currency or main card of user. Only number and name are filled, like in CardByNumber
+func (c *Card) ReceivingCard(ctx context.Context, ownerID int, currency money.Currency, token string) (model Cards, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("%s/api/cards/owner/%d/receiving?currency=%s", c.url, ownerID, url.QueryEscape(string(currency))),
+		bytes.NewBuffer(nil),
+	)
+	if err != nil {
+		return Cards{}, fmt.Errorf("can't create request: %w", err)
+	}
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	response, err := c.client.Do(request)
+	if err != nil {
+		return Cards{}, fmt.Errorf("can't send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case 200:
+		err = ReadJSONBody2(response, &model)
+		if err != nil {
+			return Cards{}, fmt.Errorf("can't parse response: %w", err)
+		}
+		model.normalize()
+		return model, nil
+	case 404:
+		return Cards{}, ErrCardNotFound
+	case 400:
+		return Cards{}, ErrResponse
+	default:
+		return Cards{}, ErrUnknown
+	}
+}
+
 func ReadJSONBody2(response *http.Response, dto interface{}) error {
 	if response.Header.Get("Content-Type") != "application/json" {
 		return errors.New("error: incorrect Content-Type")
@@ -119,26 +214,36 @@ func ReadJSONBody2(response *http.Response, dto interface{}) error {
 
 //-----------------------
 
-func (c *Card) Transfer(ctx context.Context, numberCardRecipient string, idCardSender string, count string, token string) (err error) {
+// Transfer sends amount from sender card, quote is required when recipient card has other currency
+func (c *Card) Transfer(ctx context.Context, numberCardRecipient Number, idCardSender string, amount money.Money, quote *fx.Quote, token string) (err error) {
 	// add timeout to context
-	ctx, _ = context.WithTimeout(ctx, 55*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
 
 	idCardSenderInt, err := strconv.Atoi(idCardSender)
 	if err != nil {
-		log.Printf("can't atoi: %s", err)
-		return
+		return fmt.Errorf("can't parse card id %q: %w", idCardSender, err)
 	}
 
-	countInt, err := strconv.Atoi(count)
-	if err != nil {
-		log.Printf("can't atoi: %s", err)
-		return
+	if !amount.IsPositive() {
+		return fmt.Errorf("can't transfer %s: %w", amount, money.ErrInvalidAmount)
 	}
 
 	requestData := ModelTransferMoneyCardToCard{
 		NumberCardRecipient: numberCardRecipient,
 		IdCardSender:        idCardSenderInt,
-		Count:               countInt,
+		Count:               amount,
+		Currency:            string(amount.Currency),
+	}
+	if quote != nil {
+		if quote.Amount != amount {
+			return fmt.Errorf("can't transfer %s with quote for %s", amount, quote.Amount)
+		}
+		requestData.CountRecipient = &quote.Receive
+		requestData.CurrencyRecipient = string(quote.Receive.Currency)
+		requestData.Fee = &quote.Fee
+		requestData.Rate = quote.Rate
+		requestData.QuoteID = quote.ID
 	}
 	requestBody, err := json.Marshal(requestData)
 	if err != nil {
@@ -155,7 +260,7 @@ func (c *Card) Transfer(ctx context.Context, numberCardRecipient string, idCardS
 	}
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	response, err := http.DefaultClient.Do(request)
+	response, err := c.client.Do(request)
 	if err != nil {
 		// context.Canceled
 		// context.DeadlineExceeded
@@ -163,29 +268,25 @@ func (c *Card) Transfer(ctx context.Context, numberCardRecipient string, idCardS
 	}
 	defer response.Body.Close()
 
+	// only 400 surely means money wasn't moved, other answers are ErrUnknown
 	switch response.StatusCode {
 	case 200:
-		log.Print("transfer 200 ok")
 		return nil
 	case 400:
-		log.Print("can't bad request 400")
-		return fmt.Errorf("can't bad request 400: %w", err)
-	case 500:
-		log.Print("can't server internal error 500")
-		return fmt.Errorf("can't server internal error 500: %w", err)
+		return fmt.Errorf("%w: transfer rejected", ErrResponse)
 	default:
-		return fmt.Errorf("can't transfer money: %s", response.StatusCode)
+		return fmt.Errorf("%w: transfer answered %d", ErrUnknown, response.StatusCode)
 	}
 }
 
 func (c *Card) BlockCardByID(ctx context.Context, idCardSender string, token string) (err error) {
 	// add timeout to context
-	ctx, _ = context.WithTimeout(ctx, 55*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
 
 	idCardSenderInt, err := strconv.Atoi(idCardSender)
 	if err != nil {
-		log.Printf("can't atoi: %d", err)
-		return
+		return fmt.Errorf("can't parse card id %q: %w", idCardSender, err)
 	}
 
 	model := ModelBlockCard{Id: idCardSenderInt}
@@ -204,7 +305,7 @@ func (c *Card) BlockCardByID(ctx context.Context, idCardSender string, token str
 	}
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	response, err := http.DefaultClient.Do(request)
+	response, err := c.client.Do(request)
 	if err != nil {
 		// context.Canceled
 		// context.DeadlineExceeded
@@ -214,28 +315,25 @@ func (c *Card) BlockCardByID(ctx context.Context, idCardSender string, token str
 
 	switch response.StatusCode {
 	case 200:
-		log.Print("block card 200 ok")
 		return nil
 	case 400:
-		log.Print("can't bad request 400")
 		return fmt.Errorf("can't bad request 400: %w", err)
 	case 500:
-		log.Print("can't server internal error 500")
 		return fmt.Errorf("can't server internal error 500: %w", err)
 	default:
-		return fmt.Errorf("can't block card: %s", response.StatusCode)
+		return fmt.Errorf("can't block card: %d", response.StatusCode)
 	}
 
 }
 
 func (c *Card) UnBlockCardByID(ctx context.Context, idCardSender string, token string) (err error) {
 	// add timeout to context
-	ctx, _ = context.WithTimeout(ctx, 55*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
 
 	idCardSenderInt, err := strconv.Atoi(idCardSender)
 	if err != nil {
-		log.Printf("can't atoi: %d", err)
-		return
+		return fmt.Errorf("can't parse card id %q: %w", idCardSender, err)
 	}
 
 	model := ModelBlockCard{Id: idCardSenderInt}
@@ -254,7 +352,7 @@ func (c *Card) UnBlockCardByID(ctx context.Context, idCardSender string, token s
 	}
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	response, err := http.DefaultClient.Do(request)
+	response, err := c.client.Do(request)
 	if err != nil {
 		// context.Canceled
 		// context.DeadlineExceeded
@@ -264,40 +362,28 @@ func (c *Card) UnBlockCardByID(ctx context.Context, idCardSender string, token s
 
 	switch response.StatusCode {
 	case 200:
-		log.Print("block card 200 ok")
 		return nil
 	case 400:
-		log.Print("can't bad request 400")
 		return fmt.Errorf("can't bad request 400: %w", err)
 	case 500:
-		log.Print("can't server internal error 500")
 		return fmt.Errorf("can't server internal error 500: %w", err)
 	default:
-		return fmt.Errorf("can't block card: %s", response.StatusCode)
+		return fmt.Errorf("can't block card: %d", response.StatusCode)
 	}
 
 }
 
-func (c *Card) AddCard(ctx context.Context, name string, balanceStr string, owneridStr string, token string) (err error) {
-	ctx, _ = context.WithTimeout(ctx, 55*time.Second)
-
-	balance, err := strconv.Atoi(balanceStr)
-	if err != nil {
-		log.Printf("can't atoi: %s", err)
-		return
-	}
-
-	ownerid, err := strconv.Atoi(owneridStr)
-	if err != nil {
-		log.Printf("can't atoi: %s", err)
-		return
-	}
+// AddCard opens card of ownerID, only back office may set balance other than zero
+func (c *Card) AddCard(ctx context.Context, name string, balance money.Money, ownerID int, token string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
 
 	requestData := Cards{
-		Id:      0,
-		Name:    name,
-		Balance: balance,
-		OwnerID: ownerid,
+		Id:       0,
+		Name:     name,
+		Balance:  balance,
+		Currency: balance.Currency,
+		OwnerID:  ownerID,
 	}
 	requestBody, err := json.Marshal(requestData)
 	if err != nil {
@@ -314,7 +400,7 @@ func (c *Card) AddCard(ctx context.Context, name string, balanceStr string, owne
 	}
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	response, err := http.DefaultClient.Do(request)
+	response, err := c.client.Do(request)
 	if err != nil {
 		return fmt.Errorf("can't send request: %w", err)
 	}
@@ -322,13 +408,10 @@ func (c *Card) AddCard(ctx context.Context, name string, balanceStr string, owne
 
 	switch response.StatusCode {
 	case 200:
-		log.Print("transfer 200 ok")
 		return nil
 	case 400:
-		log.Print("can't bad request 400")
 		return fmt.Errorf("can't bad request 400: %w", err)
 	case 500:
-		log.Print("can't server internal error 500")
 		return fmt.Errorf("can't server internal error 500: %w", err)
 	default:
 		return fmt.Errorf("can't add card: %d", response.StatusCode)
@@ -0,0 +1,150 @@
+package cards
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidNumber = errors.New("invalid card number")
+
+type Brand string
+
+const (
+	BrandUnknown    Brand = "Unknown"
+	BrandVisa       Brand = "Visa"
+	BrandMastercard Brand = "Mastercard"
+	BrandMaestro    Brand = "Maestro"
+	BrandMir        Brand = "Mir"
+	BrandUnionPay   Brand = "UnionPay"
+	BrandAmex       Brand = "American Express"
+	BrandKortiMilli Brand = "Korti Milli"
+	BrandUzcard     Brand = "Uzcard"
+	BrandHumo       Brand = "Humo"
+)
+
+// binRange matches first digits of number, longer prefixes win
+type binRange struct {
+	from  string
+	to    string
+	brand Brand
+}
+
+// binTable is kept in code and not in embedded data file: module is built with go 1.13,
+// go:embed needs 1.16. Table is short and changes with releases, like other constants here
+var binTable = []binRange{
+	{from: "4", to: "4", brand: BrandVisa},
+	{from: "51", to: "55", brand: BrandMastercard},
+	{from: "2221", to: "2720", brand: BrandMastercard},
+	{from: "2200", to: "2204", brand: BrandMir},
+	{from: "34", to: "34", brand: BrandAmex},
+	{from: "37", to: "37", brand: BrandAmex},
+	{from: "62", to: "62", brand: BrandUnionPay},
+	{from: "50", to: "50", brand: BrandMaestro},
+	{from: "56", to: "58", brand: BrandMaestro},
+	{from: "639", to: "639", brand: BrandMaestro},
+	{from: "67", to: "67", brand: BrandMaestro},
+	{from: "9762", to: "9762", brand: BrandKortiMilli},
+	{from: "8600", to: "8600", brand: BrandUzcard},
+	{from: "9860", to: "9860", brand: BrandHumo},
+}
+
+// Number is card number (PAN). String() is masked, so numbers are not leaked to logs and pages by accident,
+// use Grouped() when full number must be shown.
+type Number string
+
+// ParseNumber accepts number typed by user with spaces or dashes and checks length and Luhn checksum
+func ParseNumber(input string) (Number, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(input))
+
+	if len(digits) < 12 || len(digits) > 19 || !isDigits(digits) {
+		return "", fmt.Errorf("%w: wrong length or symbols", ErrInvalidNumber)
+	}
+	if !luhn(digits) {
+		return "", fmt.Errorf("%w: checksum mismatch", ErrInvalidNumber)
+	}
+	return Number(digits), nil
+}
+
+func (n Number) Valid() bool {
+	_, err := ParseNumber(string(n))
+	return err == nil
+}
+
+func (n Number) Brand() Brand {
+	best := binRange{brand: BrandUnknown}
+	for _, entry := range binTable {
+		if len(entry.from) <= len(best.from) || len(n) < len(entry.from) {
+			continue
+		}
+		prefix := string(n[:len(entry.from)])
+		if prefix >= entry.from && prefix <= entry.to {
+			best = entry
+		}
+	}
+	return best.brand
+}
+
+// Last4 is safe to show and log
+func (n Number) Last4() string {
+	if len(n) <= 4 {
+		return string(n)
+	}
+	return string(n[len(n)-4:])
+}
+
+// Grouped returns full number split by 4 digits: "4111 1111 1111 1111"
+func (n Number) Grouped() string {
+	builder := strings.Builder{}
+	for i, digit := range string(n) {
+		if i > 0 && i%4 == 0 {
+			builder.WriteRune(' ')
+		}
+		builder.WriteRune(digit)
+	}
+	return builder.String()
+}
+
+// Masked returns "**** **** **** 1234", values which don't look like card number are returned as is
+func (n Number) Masked() string {
+	if len(n) < 12 || !isDigits(string(n)) {
+		return string(n)
+	}
+	groups := (len(n) - 1) / 4
+	return strings.Repeat("**** ", groups) + n.Last4()
+}
+
+func (n Number) String() string {
+	return n.Masked()
+}
+
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
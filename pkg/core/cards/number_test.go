@@ -0,0 +1,101 @@
+package cards
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Number
+		err   error
+	}{
+		{"4111111111111111", "4111111111111111", nil},
+		{" 4111 1111 1111 1111 ", "4111111111111111", nil},
+		{"5555-5555-5555-4444", "5555555555554444", nil},
+		{"378282246310005", "378282246310005", nil},
+		{"6304000000000000", "6304000000000000", nil},
+		{"4111111111111112", "", ErrInvalidNumber},
+		{"5555555555554440", "", ErrInvalidNumber},
+		{"41111111111", "", ErrInvalidNumber},
+		{"41111111111111111111", "", ErrInvalidNumber},
+		{"4111x11111111111", "", ErrInvalidNumber},
+		{"4111_1111_1111_1111", "", ErrInvalidNumber},
+		{"", "", ErrInvalidNumber},
+		{"card number", "", ErrInvalidNumber},
+	}
+	for _, test := range tests {
+		got, err := ParseNumber(test.input)
+		if !errors.Is(err, test.err) || got != test.want {
+			t.Errorf("ParseNumber(%q) = %q, %v, want %q, %v", test.input, got, err, test.want, test.err)
+		}
+		if Number(test.input).Valid() != (err == nil) {
+			t.Errorf("Number(%q).Valid() = %v", test.input, Number(test.input).Valid())
+		}
+	}
+}
+
+func TestBrand(t *testing.T) {
+	tests := []struct {
+		number Number
+		want   Brand
+	}{
+		{"4111111111111111", BrandVisa},
+		{"5105105105105100", BrandMastercard},
+		{"5555555555554444", BrandMastercard},
+		{"2221000000000009", BrandMastercard},
+		{"2720990000000007", BrandMastercard},
+		{"2200000000000004", BrandMir},
+		{"2204000000000000", BrandMir},
+		{"2205000000000000", BrandUnknown},
+		{"340000000000009", BrandAmex},
+		{"378282246310005", BrandAmex},
+		{"6200000000000005", BrandUnionPay},
+		{"5000000000000000", BrandMaestro},
+		{"5600000000000000", BrandMaestro},
+		{"5800000000000000", BrandMaestro},
+		{"6390000000000000", BrandMaestro},
+		{"6700000000000000", BrandMaestro},
+		{"9762000000000000", BrandKortiMilli},
+		{"8600000000000000", BrandUzcard},
+		{"9860000000000000", BrandHumo},
+		{"9861000000000000", BrandUnknown},
+		{"1234567890123456", BrandUnknown},
+		{"22", BrandUnknown},
+		{"", BrandUnknown},
+	}
+	for _, test := range tests {
+		got := test.number.Brand()
+		if got != test.want {
+			t.Errorf("Number(%q).Brand() = %s, want %s", test.number, got, test.want)
+		}
+	}
+}
+
+func TestMaskedAndGrouped(t *testing.T) {
+	tests := []struct {
+		number  Number
+		masked  string
+		grouped string
+	}{
+		{"4111111111111111", "**** **** **** 1111", "4111 1111 1111 1111"},
+		{"378282246310005", "**** **** **** 0005", "3782 8224 6310 005"},
+		{"6304000000000000018", "**** **** **** **** 0018", "6304 0000 0000 0000 018"},
+		{"123456789012", "**** **** 9012", "1234 5678 9012"},
+		{"12345", "12345", "1234 5"},
+		{"=HYPERLINK(\"x\")", "=HYPERLINK(\"x\")", "=HYP ERLI NK(\" x\")"},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		if got := test.number.Masked(); got != test.masked {
+			t.Errorf("Number(%q).Masked() = %q, want %q", test.number, got, test.masked)
+		}
+		if got := test.number.String(); got != test.masked {
+			t.Errorf("Number(%q).String() = %q, want %q", test.number, got, test.masked)
+		}
+		if got := test.number.Grouped(); got != test.grouped {
+			t.Errorf("Number(%q).Grouped() = %q, want %q", test.number, got, test.grouped)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package chat
+
+import "fmt"
+
+// Attachment is file sent with message, content is kept by front and served only to participants
+type Attachment struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+func (a *Attachment) IsImage() bool {
+	return a.ContentType == "image/jpeg" || a.ContentType == "image/png"
+}
+
+// AttachmentMessage has name of file as text for chat clients that don't know attachments
+func AttachmentMessage(senderID int, recipientID int, attachment Attachment) ModelMassage {
+	return ModelMassage{
+		SenderID:    senderID,
+		RecipientID: recipientID,
+		Message:     fmt.Sprintf("📎 %s", attachment.Name),
+		Attachment:  &attachment,
+	}
+}
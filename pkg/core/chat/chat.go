@@ -6,8 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/jwt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"time"
 )
@@ -15,19 +15,23 @@ import (
 type Url string
 
 type Chat struct {
-	url Url
+	url        Url
+	client     *http.Client
+	paymentKey jwt.Secret
 }
 
-func NewChat(url Url) *Chat {
-	return &Chat{url: url}
+// NewChat signs payment messages with key derived from secret,
+// transport of client forwards request id
+func NewChat(url Url, client *http.Client, secret jwt.Secret) *Chat {
+	return &Chat{url: url, client: client, paymentKey: jwt.Derive(secret, "chat.payment")}
 }
 
 var ErrUnknown = errors.New("unknown error")
 var ErrResponse = errors.New("response error")
 
 func (c *Chat) GetAllMessage(ctx context.Context, token string) (model []ModelMassage, err error) {
-	log.Print("start func allhistory")
-	ctx, _ = context.WithTimeout(ctx, 6666*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 6666*time.Second)
+	defer cancel()
 	request, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodGet,
@@ -37,18 +41,13 @@ func (c *Chat) GetAllMessage(ctx context.Context, token string) (model []ModelMa
 	if err != nil {
 		return nil, fmt.Errorf("can't create request: %w", err)
 	}
-	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s",token))
-	response, err := http.DefaultClient.Do(request)
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	response, err := c.client.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("can't send request: %w", err)
 	}
 
-	defer func() {
-		err = response.Body.Close()
-		if err != nil {
-			log.Fatalf("can't close response body: %d", err)
-		}
-	}()
+	defer response.Body.Close()
 	err = ReadJSONBody2(response, &model)
 	if err != nil {
 		return nil, fmt.Errorf("can't parse response: %w", err)
@@ -56,8 +55,8 @@ func (c *Chat) GetAllMessage(ctx context.Context, token string) (model []ModelMa
 
 	switch response.StatusCode {
 	case 200:
-		log.Print("chat 200 ok")
-		return model,nil
+		verifyPayments(c.paymentKey, model)
+		return model, nil
 	case 400:
 
 		return nil, ErrResponse
@@ -86,10 +85,12 @@ func ReadJSONBody2(response *http.Response, dto interface{}) error {
 }
 
 type ModelMassage struct {
-	ID            int       `json:"id"`
-	SenderID      int       `json:"sender_id"`
-	RecipientID   int       `json:"recipient_id"`
-	RecipientName string    `json:"recipient_name"`
-	Message       string    `json:"message"`
-	Time          time.Time `json:"time"`
-}
\ No newline at end of file
+	ID            int         `json:"id"`
+	SenderID      int         `json:"sender_id"`
+	RecipientID   int         `json:"recipient_id"`
+	RecipientName string      `json:"recipient_name"`
+	Message       string      `json:"message"`
+	Time          time.Time   `json:"time"`
+	Payment       *Payment    `json:"payment,omitempty"`
+	Attachment    *Attachment `json:"attachment,omitempty"`
+}
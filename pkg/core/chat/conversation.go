@@ -0,0 +1,121 @@
+package chat
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Conversation is all messages between user and one counterpart ordered from oldest
+type Conversation struct {
+	CounterpartID   int
+	CounterpartName string
+	Messages        []ModelMassage
+}
+
+func (c Conversation) Last() ModelMassage {
+	if len(c.Messages) == 0 {
+		return ModelMassage{}
+	}
+	return c.Messages[len(c.Messages)-1]
+}
+
+// Counterpart returns id of other side of message for user
+func (m ModelMassage) Counterpart(userID int) int {
+	if m.SenderID == userID {
+		return m.RecipientID
+	}
+	return m.SenderID
+}
+
+func (m ModelMassage) IsOutgoing(userID int) bool {
+	return m.SenderID == userID
+}
+
+// Conversations groups messages of user by counterpart, conversation with newest message goes first
+func Conversations(messages []ModelMassage, userID int) []Conversation {
+	sorted := make([]ModelMassage, len(messages))
+	copy(sorted, messages)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Time.Equal(sorted[j].Time) {
+			return sorted[i].Time.Before(sorted[j].Time)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	byCounterpart := make(map[int]*Conversation)
+	for _, message := range sorted {
+		if message.SenderID != userID && message.RecipientID != userID {
+			continue
+		}
+		id := message.Counterpart(userID)
+		conversation, ok := byCounterpart[id]
+		if !ok {
+			conversation = &Conversation{CounterpartID: id}
+			byCounterpart[id] = conversation
+		}
+		// chat service sends only name of recipient, so name is known when user wrote to counterpart
+		if message.IsOutgoing(userID) && message.RecipientName != "" {
+			conversation.CounterpartName = message.RecipientName
+		}
+		conversation.Messages = append(conversation.Messages, message)
+	}
+
+	conversations := make([]Conversation, 0, len(byCounterpart))
+	for _, conversation := range byCounterpart {
+		if conversation.CounterpartName == "" {
+			conversation.CounterpartName = unknownName(conversation.CounterpartID)
+		}
+		conversations = append(conversations, *conversation)
+	}
+	sort.Slice(conversations, func(i, j int) bool {
+		a, b := conversations[i].Last(), conversations[j].Last()
+		if !a.Time.Equal(b.Time) {
+			return a.Time.After(b.Time)
+		}
+		return conversations[i].CounterpartID < conversations[j].CounterpartID
+	})
+	return conversations
+}
+
+// Thread returns conversation of user with counterpart, it's empty when they haven't talked yet
+func Thread(messages []ModelMassage, userID int, counterpartID int) Conversation {
+	for _, conversation := range Conversations(messages, userID) {
+		if conversation.CounterpartID == counterpartID {
+			return conversation
+		}
+	}
+	return Conversation{
+		CounterpartID:   counterpartID,
+		CounterpartName: unknownName(counterpartID),
+	}
+}
+
+func unknownName(id int) string {
+	return "Пользователь " + strconv.Itoa(id)
+}
+
+// Since returns messages of user with id greater than after ordered by id,
+// chat service gives ids in order of sending so it's used as position in stream
+func Since(messages []ModelMassage, userID int, after int) []ModelMassage {
+	fresh := make([]ModelMassage, 0)
+	for _, message := range messages {
+		if message.ID > after && (message.SenderID == userID || message.RecipientID == userID) {
+			fresh = append(fresh, message)
+		}
+	}
+	sort.Slice(fresh, func(i, j int) bool {
+		return fresh[i].ID < fresh[j].ID
+	})
+	return fresh
+}
+
+// LastID is id of newest message, zero for no messages
+func LastID(messages []ModelMassage) int {
+	last := 0
+	for _, message := range messages {
+		if message.ID > last {
+			last = message.ID
+		}
+	}
+	return last
+}
@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func at(minute int) time.Time {
+	return time.Date(2026, 3, 10, 12, minute, 0, 0, time.UTC)
+}
+
+func testMessages() []ModelMassage {
+	return []ModelMassage{
+		{ID: 5, SenderID: 1, RecipientID: 2, RecipientName: "Анвар", Message: "привет", Time: at(1)},
+		{ID: 6, SenderID: 2, RecipientID: 1, Message: "салом", Time: at(2)},
+		{ID: 7, SenderID: 3, RecipientID: 1, Message: "кто это?", Time: at(3)},
+		{ID: 8, SenderID: 2, RecipientID: 3, Message: "чужое", Time: at(4)},
+		// same time as 6, id orders them
+		{ID: 4, SenderID: 1, RecipientID: 2, Message: "раньше", Time: at(2)},
+	}
+}
+
+func TestConversations(t *testing.T) {
+	conversations := Conversations(testMessages(), 1)
+	if len(conversations) != 2 {
+		t.Fatalf("conversations = %+v, want 2", conversations)
+	}
+
+	newest := conversations[0]
+	if newest.CounterpartID != 3 || newest.CounterpartName != "Пользователь 3" || len(newest.Messages) != 1 {
+		t.Errorf("first conversation = %+v, want one message from 3 with unknown name", newest)
+	}
+	second := conversations[1]
+	if second.CounterpartID != 2 || second.CounterpartName != "Анвар" {
+		t.Errorf("second conversation = %d %s, want 2 Анвар", second.CounterpartID, second.CounterpartName)
+	}
+	var ids []int
+	for _, message := range second.Messages {
+		ids = append(ids, message.ID)
+	}
+	if len(ids) != 3 || ids[0] != 5 || ids[1] != 4 || ids[2] != 6 {
+		t.Errorf("messages of conversation = %v, want [5 4 6]", ids)
+	}
+	if second.Last().ID != 6 || !second.Last().IsOutgoing(2) || second.Last().IsOutgoing(1) {
+		t.Errorf("last message = %+v", second.Last())
+	}
+
+	if len(Conversations(nil, 1)) != 0 || (Conversation{}).Last().ID != 0 {
+		t.Errorf("empty history has conversations")
+	}
+}
+
+func TestThread(t *testing.T) {
+	thread := Thread(testMessages(), 1, 2)
+	if thread.CounterpartID != 2 || len(thread.Messages) != 3 {
+		t.Errorf("thread = %+v, want 3 messages with 2", thread)
+	}
+	// user 2 doesn't see messages of 1 and 3
+	thread = Thread(testMessages(), 2, 3)
+	if len(thread.Messages) != 1 || thread.Messages[0].ID != 8 {
+		t.Errorf("thread = %+v, want message 8", thread)
+	}
+	thread = Thread(testMessages(), 1, 9)
+	if thread.CounterpartID != 9 || thread.CounterpartName != "Пользователь 9" || len(thread.Messages) != 0 {
+		t.Errorf("new thread = %+v", thread)
+	}
+}
+
+func TestSince(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID int
+		after  int
+		want   []int
+	}{
+		{"all of user", 1, 0, []int{4, 5, 6, 7}},
+		{"after last rendered", 1, 5, []int{6, 7}},
+		{"nothing new", 1, 8, nil},
+		{"other user", 3, 0, []int{7, 8}},
+	}
+	for _, test := range tests {
+		var got []int
+		for _, message := range Since(testMessages(), test.userID, test.after) {
+			got = append(got, message.ID)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: ids = %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: ids = %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+	if LastID(testMessages()) != 8 || LastID(nil) != 0 {
+		t.Errorf("LastID() = %d, want 8", LastID(testMessages()))
+	}
+}
@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/jwt"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentCompleted PaymentStatus = "completed"
+	PaymentFailed    PaymentStatus = "failed"
+)
+
+// Payment is attached to message about transfer made from conversation. Chat service
+// accepts payment from any client, so front signs it and drops payments without valid signature
+type Payment struct {
+	ID        string         `json:"id"`
+	Amount    money.Money    `json:"amount"`
+	Currency  money.Currency `json:"currency"`
+	Status    PaymentStatus  `json:"status"`
+	Signature string         `json:"signature"`
+}
+
+func (p *Payment) normalize() {
+	if p.Currency == "" {
+		p.Currency = money.DefaultCurrency
+	}
+	p.Amount = p.Amount.WithCurrency(p.Currency)
+}
+
+// PaymentMessage is posted to thread after transfer, text is for chat clients that don't know payments
+func PaymentMessage(senderID int, recipientID int, amount money.Money, status PaymentStatus) ModelMassage {
+	text := fmt.Sprintf("Перевод %s выполнен", amount)
+	if status != PaymentCompleted {
+		text = fmt.Sprintf("Перевод %s не выполнен", amount)
+	}
+	return ModelMassage{
+		SenderID:    senderID,
+		RecipientID: recipientID,
+		Message:     text,
+		Payment: &Payment{
+			Amount:   amount,
+			Currency: amount.Currency,
+			Status:   status,
+		},
+	}
+}
+
+func (p *Payment) sign(key jwt.Secret, senderID int, recipientID int) error {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return fmt.Errorf("can't generate payment id: %w", err)
+	}
+	p.ID = hex.EncodeToString(id)
+	p.Signature = p.signature(key, senderID, recipientID)
+	return nil
+}
+
+func (p *Payment) signature(key jwt.Secret, senderID int, recipientID int) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = fmt.Fprintf(mac, "%s|%d|%d|%d|%s|%s", p.ID, senderID, recipientID, p.Amount.Amount, p.Amount.Currency, p.Status)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyPayments turns forged payments and copies of real ones into plain messages
+func verifyPayments(key jwt.Secret, messages []ModelMassage) {
+	seen := make(map[string]bool)
+	for i := range messages {
+		payment := messages[i].Payment
+		if payment == nil {
+			continue
+		}
+		payment.normalize()
+		expected := payment.signature(key, messages[i].SenderID, messages[i].RecipientID)
+		if payment.ID == "" || seen[payment.ID] || !hmac.Equal([]byte(payment.Signature), []byte(expected)) {
+			messages[i].Payment = nil
+			continue
+		}
+		seen[payment.ID] = true
+	}
+}
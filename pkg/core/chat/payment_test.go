@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"encoding/json"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/jwt"
+	"testing"
+)
+
+func signedMessage(t *testing.T, key jwt.Secret, amount money.Money) ModelMassage {
+	message := PaymentMessage(1, 2, amount, PaymentCompleted)
+	err := message.Payment.sign(key, message.SenderID, message.RecipientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// message comes back from chat service as json
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received ModelMassage
+	err = json.Unmarshal(data, &received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return received
+}
+
+func TestVerifyPayments(t *testing.T) {
+	key := jwt.Derive([]byte("secret"), "chat.payment")
+	tests := []struct {
+		name   string
+		change func(message *ModelMassage)
+		key    jwt.Secret
+		valid  bool
+	}{
+		{"valid signature", func(message *ModelMassage) {}, key, true},
+		{"tampered amount", func(message *ModelMassage) { message.Payment.Amount = money.New(1600, money.JPY) }, key, false},
+		{"tampered currency", func(message *ModelMassage) { message.Payment.Currency = money.USD }, key, false},
+		{"tampered status", func(message *ModelMassage) { message.Payment.Status = PaymentFailed }, key, false},
+		{"other recipient", func(message *ModelMassage) { message.RecipientID = 3 }, key, false},
+		{"wrong key", func(message *ModelMassage) {}, jwt.Derive([]byte("other"), "chat.payment"), false},
+		{"missing signature", func(message *ModelMassage) { message.Payment.Signature = "" }, key, false},
+		{"missing id", func(message *ModelMassage) { message.Payment.ID = "" }, key, false},
+	}
+	for _, test := range tests {
+		message := signedMessage(t, key, money.New(1500, money.JPY))
+		test.change(&message)
+		messages := []ModelMassage{message}
+		verifyPayments(test.key, messages)
+		if (messages[0].Payment != nil) != test.valid {
+			t.Errorf("%s: payment kept = %v, want %v", test.name, messages[0].Payment != nil, test.valid)
+		}
+		if test.valid && messages[0].Payment.Amount != money.New(1500, money.JPY) {
+			t.Errorf("%s: amount = %v, want 1500 JPY", test.name, messages[0].Payment.Amount)
+		}
+		if messages[0].Message == "" {
+			t.Errorf("%s: text of message is lost", test.name)
+		}
+	}
+}
+
+func TestVerifyPaymentsDropsCopies(t *testing.T) {
+	key := jwt.Derive([]byte("secret"), "chat.payment")
+	original := signedMessage(t, key, money.New(1050, money.TJS))
+	copied := original
+	payment := *original.Payment
+	copied.Payment = &payment
+	copied.ID = original.ID + 1
+
+	messages := []ModelMassage{original, copied}
+	verifyPayments(key, messages)
+	if messages[0].Payment == nil || messages[1].Payment != nil {
+		t.Errorf("payments = %v, %v, want only first one", messages[0].Payment, messages[1].Payment)
+	}
+}
+
+func TestPaymentMessage(t *testing.T) {
+	tests := []struct {
+		status PaymentStatus
+		text   string
+	}{
+		{PaymentCompleted, "Перевод 1 500 JPY выполнен"},
+		{PaymentFailed, "Перевод 1 500 JPY не выполнен"},
+	}
+	for _, test := range tests {
+		message := PaymentMessage(1, 2, money.New(1500, money.JPY), test.status)
+		err := message.Validate()
+		if err != nil {
+			t.Errorf("%s: Validate() error = %v", test.status, err)
+		}
+		if message.Message != test.text || message.Payment.Currency != money.JPY || message.Payment.Status != test.status {
+			t.Errorf("%s: message = %+v, payment = %+v", test.status, message, message.Payment)
+		}
+	}
+	message := PaymentMessage(1, 1, money.New(1500, money.JPY), PaymentCompleted)
+	if message.Validate() != ErrRecipientIsYou {
+		t.Errorf("payment message to yourself is valid")
+	}
+}
@@ -0,0 +1,105 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const MaxMessageLength = 2000
+
+var (
+	ErrEmptyMessage   = errors.New("message is empty")
+	ErrLongMessage    = errors.New("message is too long")
+	ErrBadRecipient   = errors.New("bad recipient")
+	ErrRecipientIsYou = errors.New("can't send message to yourself")
+)
+
+// Validate trims text and checks message before it's sent to chat service
+func (m *ModelMassage) Validate() error {
+	text, err := cleanText(m.Message)
+	if err != nil {
+		return err
+	}
+	m.Message = text
+	switch {
+	case m.RecipientID < 0:
+		return ErrBadRecipient
+	case m.RecipientID == m.SenderID:
+		return ErrRecipientIsYou
+	}
+	return nil
+}
+
+func cleanText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return "", ErrEmptyMessage
+	case utf8.RuneCountInString(text) > MaxMessageLength:
+		return "", ErrLongMessage
+	}
+	return text, nil
+}
+
+// SendMessage posts message from user of token, chat service sets sender, id and time itself
+func (c *Chat) SendMessage(ctx context.Context, message ModelMassage, token string) (ModelMassage, error) {
+	err := message.Validate()
+	if err != nil {
+		return ModelMassage{}, err
+	}
+	if message.Payment != nil {
+		err = message.Payment.sign(c.paymentKey, message.SenderID, message.RecipientID)
+		if err != nil {
+			return ModelMassage{}, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	requestBody, err := json.Marshal(message)
+	if err != nil {
+		return ModelMassage{}, fmt.Errorf("can't encode message: %w", err)
+	}
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("%s/api/chat/message", c.url),
+		bytes.NewBuffer(requestBody),
+	)
+	if err != nil {
+		return ModelMassage{}, fmt.Errorf("can't create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	response, err := c.client.Do(request)
+	if err != nil {
+		return ModelMassage{}, fmt.Errorf("can't send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case 200, 201:
+	case 400:
+		return ModelMassage{}, ErrResponse
+	default:
+		return ModelMassage{}, ErrUnknown
+	}
+
+	// old chat service answers without body
+	var sent ModelMassage
+	err = ReadJSONBody2(response, &sent)
+	if err != nil || sent.ID == 0 {
+		message.Time = time.Now()
+		return message, nil
+	}
+	messages := []ModelMassage{sent}
+	verifyPayments(c.paymentKey, messages)
+	return messages[0], nil
+}
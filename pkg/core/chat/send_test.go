@@ -0,0 +1,93 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		message ModelMassage
+		text    string
+		err     error
+	}{
+		{"trimmed", ModelMassage{SenderID: 1, RecipientID: 2, Message: "  салом \n"}, "салом", nil},
+		{"empty", ModelMassage{SenderID: 1, RecipientID: 2, Message: " \t\n"}, "", ErrEmptyMessage},
+		{"longest", ModelMassage{SenderID: 1, RecipientID: 2, Message: strings.Repeat("я", MaxMessageLength)}, strings.Repeat("я", MaxMessageLength), nil},
+		{"too long", ModelMassage{SenderID: 1, RecipientID: 2, Message: strings.Repeat("я", MaxMessageLength+1)}, "", ErrLongMessage},
+		{"bad recipient", ModelMassage{SenderID: 1, RecipientID: -1, Message: "hi"}, "", ErrBadRecipient},
+		{"yourself", ModelMassage{SenderID: 1, RecipientID: 1, Message: "hi"}, "", ErrRecipientIsYou},
+	}
+	for _, test := range tests {
+		message := test.message
+		err := message.Validate()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+		}
+		if err == nil && message.Message != test.text {
+			t.Errorf("%s: text = %q, want %q", test.name, message.Message, test.text)
+		}
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		id     int
+		err    error
+	}{
+		{"sent", 201, `{"id":12,"sender_id":1,"recipient_id":2,"message":"салом","time":"2026-03-10T12:00:00Z"}`, 12, nil},
+		{"old service", 200, ``, 0, nil},
+		{"rejected", 400, ``, 0, ErrResponse},
+		{"server error", 500, ``, 0, ErrUnknown},
+	}
+	for _, test := range tests {
+		var sent ModelMassage
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method != http.MethodPost || request.URL.Path != "/api/chat/message" || request.Header.Get("Authorization") != "Bearer token" {
+				t.Errorf("%s: request %s %s", test.name, request.Method, request.URL.Path)
+			}
+			body, _ := ioutil.ReadAll(request.Body)
+			_ = json.Unmarshal(body, &sent)
+			if test.body != "" {
+				writer.Header().Set("Content-Type", "application/json")
+			}
+			writer.WriteHeader(test.status)
+			_, _ = writer.Write([]byte(test.body))
+		}))
+
+		message, err := NewChat(Url(server.URL), server.Client(), []byte("secret")).SendMessage(context.Background(), ModelMassage{SenderID: 1, RecipientID: 2, Message: " салом "}, "token")
+		server.Close()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if sent.Message != "салом" || sent.RecipientID != 2 {
+			t.Errorf("%s: sent %+v", test.name, sent)
+		}
+		if err == nil && (message.ID != test.id || message.Message != "салом" || message.Time.IsZero()) {
+			t.Errorf("%s: message = %+v", test.name, message)
+		}
+	}
+}
+
+func TestSendMessageNotValid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Errorf("message isn't valid, but is sent")
+	}))
+	defer server.Close()
+
+	_, err := NewChat(Url(server.URL), server.Client(), []byte("secret")).SendMessage(context.Background(), ModelMassage{SenderID: 1, RecipientID: 2}, "token")
+	if !errors.Is(err, ErrEmptyMessage) {
+		t.Errorf("error = %v, want ErrEmptyMessage", err)
+	}
+}
@@ -0,0 +1,232 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Support conversations are kept by chat service like peer messages, but customer talks
+// to the bank and any agent may take conversation from queue
+type TicketStatus string
+
+const (
+	TicketOpen     TicketStatus = "open"
+	TicketAssigned TicketStatus = "assigned"
+	TicketClosed   TicketStatus = "closed"
+)
+
+const MaxSubjectLength = 100
+
+var (
+	ErrEmptySubject   = errors.New("subject is empty")
+	ErrLongSubject    = errors.New("subject is too long")
+	ErrTicketNotFound = errors.New("ticket not found")
+	ErrTicketClosed   = errors.New("ticket is closed")
+	ErrTicketClaimed  = errors.New("ticket is claimed by another agent")
+	ErrNotAssigned    = errors.New("ticket isn't assigned to agent")
+	ErrForbidden      = errors.New("action isn't allowed")
+)
+
+type TicketMessage struct {
+	ID        int       `json:"id"`
+	TicketID  int       `json:"ticket_id"`
+	AuthorID  int       `json:"author_id"`
+	FromAgent bool      `json:"from_agent"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// Ticket is support conversation, AgentID is set only for assigned and closed tickets.
+// Unread counters are kept by chat service separately for customer and agents
+type Ticket struct {
+	ID             int             `json:"id"`
+	CustomerID     int             `json:"customer_id"`
+	Subject        string          `json:"subject"`
+	Status         TicketStatus    `json:"status"`
+	AgentID        int             `json:"agent_id"`
+	UnreadCustomer int             `json:"unread_customer"`
+	UnreadAgent    int             `json:"unread_agent"`
+	Created        time.Time       `json:"created"`
+	Updated        time.Time       `json:"updated"`
+	Messages       []TicketMessage `json:"messages,omitempty"`
+}
+
+func (t Ticket) IsClosed() bool {
+	return t.Status == TicketClosed
+}
+
+// CanClaim tells if agent may take ticket, assigned ticket may be taken over only by its agent
+func (t Ticket) CanClaim(agentID int) error {
+	switch {
+	case t.Status == TicketClosed:
+		return ErrTicketClosed
+	case t.Status == TicketAssigned && t.AgentID != agentID:
+		return ErrTicketClaimed
+	}
+	return nil
+}
+
+// CanAnswer tells if agent may reply to or close ticket, agent claims ticket first
+func (t Ticket) CanAnswer(agentID int) error {
+	switch {
+	case t.Status == TicketClosed:
+		return ErrTicketClosed
+	case t.Status != TicketAssigned || t.AgentID != agentID:
+		return ErrNotAssigned
+	}
+	return nil
+}
+
+// Unread is count of messages not seen by side of user
+func (t Ticket) Unread(agent bool) int {
+	if agent {
+		return t.UnreadAgent
+	}
+	return t.UnreadCustomer
+}
+
+// Queue splits tickets for agent console: waiting for agent, assigned to agent and to others.
+// Closed tickets aren't shown
+func Queue(tickets []Ticket, agentID int) (waiting []Ticket, mine []Ticket, others []Ticket) {
+	for _, ticket := range tickets {
+		switch {
+		case ticket.Status == TicketOpen:
+			waiting = append(waiting, ticket)
+		case ticket.Status == TicketAssigned && ticket.AgentID == agentID:
+			mine = append(mine, ticket)
+		case ticket.Status == TicketAssigned:
+			others = append(others, ticket)
+		}
+	}
+	return waiting, mine, others
+}
+
+func validateSubject(subject string) (string, error) {
+	subject = strings.TrimSpace(subject)
+	switch {
+	case subject == "":
+		return "", ErrEmptySubject
+	case utf8.RuneCountInString(subject) > MaxSubjectLength:
+		return "", ErrLongSubject
+	}
+	return subject, nil
+}
+
+// OpenTicket starts support conversation of customer from token with first message
+func (c *Chat) OpenTicket(ctx context.Context, subject string, text string, token string) (Ticket, error) {
+	subject, err := validateSubject(subject)
+	if err != nil {
+		return Ticket{}, err
+	}
+	text, err = cleanText(text)
+	if err != nil {
+		return Ticket{}, err
+	}
+	var ticket Ticket
+	err = c.support(ctx, http.MethodPost, "/api/chat/support", struct {
+		Subject string `json:"subject"`
+		Message string `json:"message"`
+	}{subject, text}, token, &ticket)
+	return ticket, err
+}
+
+// Tickets returns tickets of customer, for agent token chat service returns tickets of all customers
+func (c *Chat) Tickets(ctx context.Context, token string) ([]Ticket, error) {
+	tickets := make([]Ticket, 0)
+	err := c.support(ctx, http.MethodGet, "/api/chat/support", nil, token, &tickets)
+	return tickets, err
+}
+
+// Ticket returns ticket with messages
+func (c *Chat) Ticket(ctx context.Context, id int, token string) (Ticket, error) {
+	var ticket Ticket
+	err := c.support(ctx, http.MethodGet, fmt.Sprintf("/api/chat/support/%d", id), nil, token, &ticket)
+	return ticket, err
+}
+
+// Reply adds message to ticket, chat service knows from token if it's customer or agent
+func (c *Chat) Reply(ctx context.Context, id int, text string, token string) error {
+	text, err := cleanText(text)
+	if err != nil {
+		return err
+	}
+	return c.support(ctx, http.MethodPost, fmt.Sprintf("/api/chat/support/%d/messages", id), struct {
+		Message string `json:"message"`
+	}{text}, token, nil)
+}
+
+// Claim assigns ticket to agent from token
+func (c *Chat) Claim(ctx context.Context, id int, token string) error {
+	return c.support(ctx, http.MethodPost, fmt.Sprintf("/api/chat/support/%d/claim", id), nil, token, nil)
+}
+
+func (c *Chat) CloseTicket(ctx context.Context, id int, token string) error {
+	return c.support(ctx, http.MethodPost, fmt.Sprintf("/api/chat/support/%d/close", id), nil, token, nil)
+}
+
+// MarkRead resets unread counter of side of user from token
+func (c *Chat) MarkRead(ctx context.Context, id int, token string) error {
+	return c.support(ctx, http.MethodPost, fmt.Sprintf("/api/chat/support/%d/read", id), nil, token, nil)
+}
+
+// support sends request to support api of chat service, result may be nil when body isn't needed
+func (c *Chat) support(ctx context.Context, method string, path string, data interface{}, token string, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var requestBody []byte
+	if data != nil {
+		var err error
+		requestBody, err = json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("can't encode request: %w", err)
+		}
+	}
+	request, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s%s", c.url, path), bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("can't create request: %w", err)
+	}
+	if data != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	response, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("can't send request: %w", err)
+	}
+	defer response.Body.Close()
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("can't read response: %w", err)
+	}
+
+	switch response.StatusCode {
+	case 200, 201, 204:
+	case 400:
+		return ErrResponse
+	case 403:
+		return ErrForbidden
+	case 409:
+		return ErrTicketClaimed
+	case 404:
+		return ErrTicketNotFound
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknown, response.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	err = json.Unmarshal(responseBody, result)
+	if err != nil {
+		return fmt.Errorf("can't decode response: %w", err)
+	}
+	return nil
+}
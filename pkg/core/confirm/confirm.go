@@ -0,0 +1,114 @@
+package confirm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/jwt"
+	"sync"
+	"time"
+)
+
+var ErrBadToken = errors.New("bad confirmation token")
+var ErrExpired = errors.New("confirmation token expired")
+var ErrUsed = errors.New("confirmation token already used")
+
+// TokenType is put to typ claim, session middleware rejects tokens with typ
+const TokenType = "confirm"
+
+// Payload is signed into confirmation token, Data holds operation parameters
+type Payload struct {
+	Type    string            `json:"typ"`
+	Nonce   string            `json:"nonce"`
+	Subject int               `json:"sub"`
+	Action  string            `json:"action"`
+	Data    map[string]string `json:"data"`
+	Exp     int64             `json:"exp"`
+}
+
+// Confirmer issues signed one-time tokens for operations which need review step
+type Confirmer struct {
+	secret jwt.Secret
+	ttl    time.Duration
+	now    func() time.Time
+	mutex  sync.Mutex
+	used   map[string]int64
+}
+
+// NewConfirmer signs tokens with key derived from secret, so confirmation token
+// can't pass signature check as session token and vice versa
+func NewConfirmer(secret jwt.Secret, ttl time.Duration) *Confirmer {
+	return &Confirmer{
+		secret: jwt.Derive(secret, TokenType),
+		ttl:    ttl,
+		now:    time.Now,
+		used:   make(map[string]int64),
+	}
+}
+
+func (c *Confirmer) TTL() time.Duration {
+	return c.ttl
+}
+
+func (c *Confirmer) Issue(subject int, action string, data map[string]string) (token string, err error) {
+	nonce := make([]byte, 16)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("can't generate nonce: %w", err)
+	}
+	payload := Payload{
+		Type:    TokenType,
+		Nonce:   hex.EncodeToString(nonce),
+		Subject: subject,
+		Action:  action,
+		Data:    data,
+		Exp:     c.now().Add(c.ttl).Unix(),
+	}
+	return jwt.Encode(payload, c.secret)
+}
+
+// Redeem checks token and marks it used, so second submit of the same form fails with ErrUsed
+func (c *Confirmer) Redeem(token string, subject int, action string) (Payload, error) {
+	ok, err := jwt.Verify(token, c.secret)
+	if err != nil || !ok {
+		return Payload{}, ErrBadToken
+	}
+	var payload Payload
+	err = jwt.Decode(token, &payload)
+	if err != nil {
+		return Payload{}, ErrBadToken
+	}
+	if payload.Type != TokenType || payload.Subject != subject || payload.Action != action || payload.Nonce == "" {
+		return Payload{}, ErrBadToken
+	}
+
+	now := c.now()
+	ok, err = jwt.IsNotExpired(&payload, now)
+	if err != nil {
+		return Payload{}, ErrBadToken
+	}
+	if !ok {
+		return Payload{}, ErrExpired
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for nonce, exp := range c.used {
+		if exp <= now.Unix() {
+			delete(c.used, nonce)
+		}
+	}
+	if _, exists := c.used[payload.Nonce]; exists {
+		return Payload{}, ErrUsed
+	}
+	c.used[payload.Nonce] = payload.Exp
+	return payload, nil
+}
+
+// Release makes redeemed token usable again, it's called when operation surely wasn't executed
+func (c *Confirmer) Release(payload Payload) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.used, payload.Nonce)
+}
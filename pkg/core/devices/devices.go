@@ -0,0 +1,86 @@
+package devices
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Device is browser user logged in from, it is recognized by id kept in long living cookie
+type Device struct {
+	ID        string    `json:"id"`
+	UserID    int       `json:"user_id"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+var idPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// NewID makes id for cookie of new device
+func NewID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", fmt.Errorf("can't generate device id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// ValidID tells if id from cookie could be made by NewID
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+type Store struct {
+	mutex sync.Mutex
+	file  *storage.File
+	items []Device
+}
+
+func NewStore(file *storage.File) (*Store, error) {
+	store := &Store{file: file}
+	err := file.Load(&store.items)
+	if err != nil {
+		return nil, fmt.Errorf("can't load devices: %w", err)
+	}
+	return store, nil
+}
+
+// Seen remembers login of user from device, result tells if device is new for user
+// and if user had other devices before, first login at all isn't suspicious
+func (s *Store) Seen(device Device, now time.Time) (isNew bool, hadOthers bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.items {
+		item := &s.items[i]
+		if item.UserID != device.UserID {
+			continue
+		}
+		if item.ID == device.ID {
+			item.LastSeen = now
+			item.UserAgent = device.UserAgent
+			item.IP = device.IP
+			return false, true, s.save()
+		}
+		hadOthers = true
+	}
+	device.FirstSeen = now
+	device.LastSeen = now
+	s.items = append(s.items, device)
+	return true, hadOthers, s.save()
+}
+
+func (s *Store) save() error {
+	err := s.file.Save(s.items)
+	if err != nil {
+		return fmt.Errorf("can't save devices: %w", err)
+	}
+	return nil
+}
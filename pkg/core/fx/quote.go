@@ -0,0 +1,152 @@
+package fx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"sync"
+	"time"
+)
+
+var ErrQuoteNotFound = errors.New("quote not found")
+var ErrQuoteExpired = errors.New("quote expired")
+var ErrQuoteMismatch = errors.New("quote is for other transfer")
+
+// Parties binds quote to transfer it was calculated for
+type Parties struct {
+	OwnerID   int
+	SenderID  int
+	Recipient string
+}
+
+// Quote is conversion offer shown to user before transfer
+type Quote struct {
+	ID        string
+	Parties   Parties
+	Amount    money.Money // what sender transfers, sender card currency
+	Fee       money.Money // conversion fee, sender card currency
+	Debit     money.Money // Amount + Fee
+	Receive   money.Money // what recipient gets, recipient card currency
+	Rate      string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Quoter calculates quotes and locks them for ttl until transfer is confirmed
+type Quoter struct {
+	provider Provider
+	feeBps   int64
+	ttl      time.Duration
+	now      func() time.Time
+	mutex    sync.Mutex
+	quotes   map[string]Quote
+}
+
+func NewQuoter(provider Provider, feeBps int64, ttl time.Duration) *Quoter {
+	return &Quoter{
+		provider: provider,
+		feeBps:   feeBps,
+		ttl:      ttl,
+		now:      time.Now,
+		quotes:   make(map[string]Quote),
+	}
+}
+
+func (q *Quoter) TTL() time.Duration {
+	return q.ttl
+}
+
+// Quote calculates and locks quote for converting amount to currency in transfer between parties
+func (q *Quoter) Quote(ctx context.Context, parties Parties, amount money.Money, to money.Currency) (Quote, error) {
+	rate, err := q.provider.Rate(ctx, amount.Currency, to)
+	if err != nil {
+		return Quote{}, fmt.Errorf("can't get rate %s/%s: %w", amount.Currency, to, err)
+	}
+
+	fee := money.New(ceilBps(amount.Amount, q.feeBps), amount.Currency)
+	debit, err := amount.Add(fee)
+	if err != nil {
+		return Quote{}, fmt.Errorf("can't calculate debit: %w", err)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Quote{}, err
+	}
+
+	now := q.now()
+	quote := Quote{
+		ID:        id,
+		Parties:   parties,
+		Amount:    amount,
+		Fee:       fee,
+		Debit:     debit,
+		Receive:   Convert(amount, to, rate),
+		Rate:      rate.FloatString(6),
+		CreatedAt: now,
+		ExpiresAt: now.Add(q.ttl),
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.removeExpired(now)
+	q.quotes[id] = quote
+	return quote, nil
+}
+
+// Take returns locked quote and forgets it, so it can be executed only once.
+// Quote of other user or cards is left locked for its owner
+func (q *Quoter) Take(id string, parties Parties) (Quote, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	quote, ok := q.quotes[id]
+	if !ok {
+		return Quote{}, ErrQuoteNotFound
+	}
+	if quote.Parties != parties {
+		return Quote{}, ErrQuoteMismatch
+	}
+	delete(q.quotes, id)
+
+	if !q.now().Before(quote.ExpiresAt) {
+		return Quote{}, ErrQuoteExpired
+	}
+	return quote, nil
+}
+
+// Return locks taken quote again when transfer wasn't executed, so user can retry it
+func (q *Quoter) Return(quote Quote) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.now().Before(quote.ExpiresAt) {
+		q.quotes[quote.ID] = quote
+	}
+}
+
+func (q *Quoter) removeExpired(now time.Time) {
+	for id, quote := range q.quotes {
+		if !now.Before(quote.ExpiresAt) {
+			delete(q.quotes, id)
+		}
+	}
+}
+
+func ceilBps(amount int64, bps int64) int64 {
+	if amount <= 0 || bps <= 0 {
+		return 0
+	}
+	return (amount*bps + 9999) / 10000
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("can't generate id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,89 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type fixedRate struct{}
+
+func (fixedRate) Rate(_ context.Context, from money.Currency, to money.Currency) (*big.Rat, error) {
+	return big.NewRat(1092, 100), nil
+}
+
+func TestTakeChecksParties(t *testing.T) {
+	quoter := NewQuoter(fixedRate{}, 100, time.Minute)
+	parties := Parties{OwnerID: 1, SenderID: 10, Recipient: "2200000000000004"}
+	quote, err := quoter.Quote(context.Background(), parties, money.New(10000, money.USD), money.TJS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	others := []Parties{
+		{OwnerID: 2, SenderID: 10, Recipient: "2200000000000004"},
+		{OwnerID: 1, SenderID: 11, Recipient: "2200000000000004"},
+		{OwnerID: 1, SenderID: 10, Recipient: "4111111111111111"},
+	}
+	for _, other := range others {
+		_, err = quoter.Take(quote.ID, other)
+		if !errors.Is(err, ErrQuoteMismatch) {
+			t.Errorf("Take(%+v) error = %v, want ErrQuoteMismatch", other, err)
+		}
+	}
+
+	taken, err := quoter.Take(quote.ID, parties)
+	if err != nil || taken.Receive != money.New(109200, money.TJS) || taken.Fee != money.New(100, money.USD) {
+		t.Errorf("Take() = %+v, %v", taken, err)
+	}
+	_, err = quoter.Take(quote.ID, parties)
+	if !errors.Is(err, ErrQuoteNotFound) {
+		t.Errorf("second Take() error = %v, want ErrQuoteNotFound", err)
+	}
+}
+
+func TestTakeExpired(t *testing.T) {
+	quoter := NewQuoter(fixedRate{}, 0, time.Minute)
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	quoter.now = func() time.Time { return now }
+	parties := Parties{OwnerID: 1, SenderID: 10, Recipient: "2200000000000004"}
+	quote, err := quoter.Quote(context.Background(), parties, money.New(100, money.USD), money.TJS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	_, err = quoter.Take(quote.ID, parties)
+	if !errors.Is(err, ErrQuoteExpired) {
+		t.Errorf("Take() error = %v, want ErrQuoteExpired", err)
+	}
+}
+
+func TestReturn(t *testing.T) {
+	quoter := NewQuoter(fixedRate{}, 0, time.Minute)
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	quoter.now = func() time.Time { return now }
+	parties := Parties{OwnerID: 1, SenderID: 10, Recipient: "2200000000000004"}
+	quote, err := quoter.Quote(context.Background(), parties, money.New(100, money.USD), money.TJS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken, err := quoter.Take(quote.ID, parties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quoter.Return(taken)
+	_, err = quoter.Take(quote.ID, parties)
+	if err != nil {
+		t.Errorf("Take() of returned quote error = %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	quoter.Return(taken)
+	_, err = quoter.Take(quote.ID, parties)
+	if !errors.Is(err, ErrQuoteNotFound) {
+		t.Errorf("Take() of expired returned quote error = %v, want ErrQuoteNotFound", err)
+	}
+}
@@ -0,0 +1,129 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io/ioutil"
+	"math/big"
+	"sync"
+)
+
+var ErrRateNotFound = errors.New("rate not found")
+
+// Provider gives rate: how many units of `to` for one unit of `from`
+type Provider interface {
+	Rate(ctx context.Context, from money.Currency, to money.Currency) (*big.Rat, error)
+}
+
+// StaticProvider reads rates from json file for local use:
+// {"base": "USD", "rates": {"TJS": "10.92", "RUB": "92.50"}}
+type StaticProvider struct {
+	mutex sync.RWMutex
+	base  money.Currency
+	rates map[money.Currency]*big.Rat
+}
+
+type staticFile struct {
+	Base  string            `json:"base"`
+	Rates map[string]string `json:"rates"`
+}
+
+func NewStaticProvider(path string) (*StaticProvider, error) {
+	provider := &StaticProvider{}
+	err := provider.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func (p *StaticProvider) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("can't read rates file %s: %w", path, err)
+	}
+
+	var file staticFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return fmt.Errorf("can't decode rates file %s: %w", path, err)
+	}
+
+	base, err := money.ParseCurrency(file.Base)
+	if err != nil {
+		return fmt.Errorf("bad base currency in %s: %w", path, err)
+	}
+	rates := map[money.Currency]*big.Rat{base: big.NewRat(1, 1)}
+	for code, value := range file.Rates {
+		currency, err := money.ParseCurrency(code)
+		if err != nil {
+			return fmt.Errorf("bad currency in %s: %w", path, err)
+		}
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return fmt.Errorf("bad rate for %s in %s: %s", code, path, value)
+		}
+		rates[currency] = rate
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.base = base
+	p.rates = rates
+	return nil
+}
+
+func (p *StaticProvider) Rate(_ context.Context, from money.Currency, to money.Currency) (*big.Rat, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	fromRate, ok := p.rates[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRateNotFound, from)
+	}
+	toRate, ok := p.rates[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRateNotFound, to)
+	}
+	// both are relative to base
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+// Convert amount to other currency, rounding half away from zero
+func Convert(amount money.Money, to money.Currency, rate *big.Rat) money.Money {
+	value := new(big.Rat).SetInt64(amount.Amount)
+	value.Mul(value, rate)
+	value.Mul(value, pow10(to.Exponent()-amount.Currency.Exponent()))
+	return money.New(round(value), to)
+}
+
+func pow10(exponent int) *big.Rat {
+	value := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exponent))), nil)
+	if exponent < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), value)
+	}
+	return new(big.Rat).SetInt(value)
+}
+
+func round(value *big.Rat) int64 {
+	numerator := new(big.Int).Abs(value.Num())
+	denominator := value.Denom()
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Mul(remainder, big.NewInt(2)).Cmp(denominator) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient.Int64()
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
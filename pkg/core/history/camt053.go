@@ -0,0 +1,124 @@
+package history
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ISO 20022 bank to customer statement, version 02 is still the one most tools import
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+const (
+	camtDateTime = "2006-01-02T15:04:05"
+	camtDate     = "2006-01-02"
+)
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// newCamtAmount splits signed money to amount and credit/debit indicator, camt has no negative amounts
+func newCamtAmount(amount money.Money) (camtAmount, string) {
+	indicator := "CRDT"
+	if amount.IsNegative() {
+		indicator = "DBIT"
+		amount = amount.Neg()
+	}
+	return camtAmount{Currency: string(amount.Currency), Value: money.FormatAmount(amount, money.LocaleISO)}, indicator
+}
+
+type camtGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	Created   string `xml:"CreDtTm"`
+}
+
+type camtPeriod struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+}
+
+type camtEntry struct {
+	Reference   string     `xml:"NtryRef"`
+	Amount      camtAmount `xml:"Amt"`
+	Indicator   string     `xml:"CdtDbtInd"`
+	Status      string     `xml:"Sts"`
+	Booked      string     `xml:"BookgDt>DtTm"`
+	Value       string     `xml:"ValDt>Dt"`
+	ServicerRef string     `xml:"AcctSvcrRef"`
+	Code        string     `xml:"BkTxCd>Prtry>Cd"`
+	Info        string     `xml:"AddtlNtryInf,omitempty"`
+}
+
+func exportCamt053(w io.Writer, statements []Statement, now time.Time) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	messageID := fmt.Sprintf("STMT%s", now.Format("20060102150405"))
+	tags := &xmlTags{encoder: encoder}
+	tags.open("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace})
+	tags.open("BkToCstmrStmt")
+	tags.element("GrpHdr", camtGroupHeader{MessageID: messageID, Created: now.Format(camtDateTime)})
+	for i, statement := range statements {
+		tags.open("Stmt")
+		tags.element("Id", fmt.Sprintf("%s-%d", messageID, i+1))
+		tags.element("CreDtTm", now.Format(camtDateTime))
+		tags.element("FrToDt", camtPeriod{From: statement.From.Format(camtDateTime), To: statement.To.Format(camtDateTime)})
+		tags.element("Acct", camtAccount{ID: statement.AccountID, Currency: string(statement.Currency)})
+
+		balances := []struct {
+			code   string
+			amount money.Money
+			date   time.Time
+		}{
+			{"OPBD", statement.Opening(), statement.From},
+			{"CLBD", statement.Closing(), statement.To},
+		}
+		for _, balance := range balances {
+			amount, indicator := newCamtAmount(balance.amount)
+			tags.element("Bal", camtBalance{Code: balance.code, Amount: amount, Indicator: indicator, Date: balance.date.Format(camtDate)})
+		}
+
+		for _, operation := range statement.Operations {
+			amount, indicator := newCamtAmount(operation.Signed())
+			entry := camtEntry{
+				Reference:   strconv.Itoa(operation.Id),
+				Amount:      amount,
+				Indicator:   indicator,
+				Status:      "BOOK",
+				Booked:      operation.Moment().Format(camtDateTime),
+				Value:       operation.Moment().Format(camtDate),
+				ServicerRef: strconv.Itoa(operation.Id),
+				Code:        operation.Name,
+			}
+			if operation.RecipientSender != "" {
+				entry.Info = operation.RecipientSender.String()
+			}
+			tags.element("Ntry", entry)
+		}
+		tags.close()
+	}
+	tags.close()
+	tags.close()
+	return tags.flush()
+}
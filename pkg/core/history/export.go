@@ -0,0 +1,288 @@
+package history
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatOFX     Format = "ofx"
+	FormatCamt053 Format = "camt053"
+)
+
+// ParseFormat returns csv for empty value
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(value))); format {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatOFX, FormatCamt053:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, value)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatCamt053:
+		return "application/xml"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatOFX:
+		return "ofx"
+	case FormatCamt053:
+		return "xml"
+	default:
+		return "csv"
+	}
+}
+
+// IsDebit reports whether money left the card, history service doesn't send direction
+func (o ModelOperationsLog) IsDebit() bool {
+	return o.BalanceNew.Amount < o.BalanceOld.Amount
+}
+
+// Signed returns amount with minus for debit
+func (o ModelOperationsLog) Signed() money.Money {
+	if o.IsDebit() {
+		return o.Count.Neg()
+	}
+	return o.Count
+}
+
+func (o ModelOperationsLog) Moment() time.Time {
+	return time.Unix(o.Time, 0)
+}
+
+func (o *ModelOperationsLog) setCurrency(currency money.Currency) {
+	o.Count = o.Count.WithCurrency(currency)
+	o.BalanceOld = o.BalanceOld.WithCurrency(currency)
+	o.BalanceNew = o.BalanceNew.WithCurrency(currency)
+}
+
+// SetCurrencies puts currencies of cards to amounts as history service sends bare amounts
+func SetCurrencies(operations []ModelOperationsLog, currencies map[cards.Number]money.Currency) {
+	for i := range operations {
+		if currency, ok := currencies[operations[i].Number]; ok && currency != "" {
+			operations[i].setCurrency(currency)
+		}
+	}
+}
+
+type Direction string
+
+const (
+	DirectionIn  Direction = "in"
+	DirectionOut Direction = "out"
+)
+
+// Filter selects operations, zero fields match everything, To is exclusive.
+// Amounts are in currency of card and compared without sign. CardID is sent to history service
+// instead of Number, so card number doesn't get into urls and logs
+type Filter struct {
+	ID           int
+	From         time.Time
+	To           time.Time
+	CardID       int
+	Number       cards.Number
+	Direction    Direction
+	MinAmount    money.Money
+	MaxAmount    money.Money
+	Counterparty string
+}
+
+func (f Filter) Match(operation ModelOperationsLog) bool {
+	moment := operation.Moment()
+	switch {
+	case f.ID != 0 && operation.Id != f.ID:
+		return false
+	case !f.From.IsZero() && moment.Before(f.From):
+		return false
+	case !f.To.IsZero() && !moment.Before(f.To):
+		return false
+	case f.Number != "" && operation.Number != f.Number:
+		return false
+	case f.Direction == DirectionIn && operation.IsDebit():
+		return false
+	case f.Direction == DirectionOut && !operation.IsDebit():
+		return false
+	case !f.MinAmount.IsZero() && operation.Count.Amount < f.MinAmount.Amount:
+		return false
+	case !f.MaxAmount.IsZero() && operation.Count.Amount > f.MaxAmount.Amount:
+		return false
+	case f.Counterparty != "" && !strings.Contains(string(operation.RecipientSender), f.Counterparty):
+		return false
+	}
+	return true
+}
+
+// Statement is operations of one card for period ordered by time.
+// AccountID is id of card in cards service, it doesn't change and reveals nothing
+type Statement struct {
+	Number     cards.Number
+	AccountID  string
+	Currency   money.Currency
+	From       time.Time
+	To         time.Time
+	Operations []ModelOperationsLog
+}
+
+// NewStatements groups operations matching filter by card. Cards of user give currency
+// (history service sends bare amounts) and account id, operations of other cards are skipped
+func NewStatements(operations []ModelOperationsLog, filter Filter, allCards []cards.Cards) []Statement {
+	byNumber := make(map[cards.Number]*Statement)
+	numbers := make([]cards.Number, 0)
+	for _, operation := range operations {
+		if !filter.Match(operation) {
+			continue
+		}
+		statement, ok := byNumber[operation.Number]
+		if !ok {
+			card, found := findCard(allCards, operation.Number)
+			if !found {
+				continue
+			}
+			currency := card.Currency
+			if currency == "" {
+				currency = money.DefaultCurrency
+			}
+			statement = &Statement{
+				Number:    operation.Number,
+				AccountID: strconv.Itoa(card.Id),
+				Currency:  currency,
+				From:      filter.From,
+				To:        filter.To,
+			}
+			byNumber[operation.Number] = statement
+			numbers = append(numbers, operation.Number)
+		}
+		operation.setCurrency(statement.Currency)
+		statement.Operations = append(statement.Operations, operation)
+	}
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+
+	statements := make([]Statement, 0, len(numbers))
+	for _, number := range numbers {
+		statement := byNumber[number]
+		sort.SliceStable(statement.Operations, func(i, j int) bool {
+			return statement.Operations[i].Time < statement.Operations[j].Time
+		})
+		if statement.From.IsZero() {
+			statement.From = statement.Operations[0].Moment()
+		}
+		if statement.To.IsZero() {
+			statement.To = statement.Operations[len(statement.Operations)-1].Moment()
+		}
+		statements = append(statements, *statement)
+	}
+	return statements
+}
+
+func findCard(allCards []cards.Cards, number cards.Number) (cards.Cards, bool) {
+	for _, card := range allCards {
+		if card.Number == number {
+			return card, true
+		}
+	}
+	return cards.Cards{}, false
+}
+
+// Opening is balance before first operation of statement
+func (s Statement) Opening() money.Money {
+	if len(s.Operations) == 0 {
+		return money.New(0, s.Currency)
+	}
+	return s.Operations[0].BalanceOld
+}
+
+func (s Statement) Closing() money.Money {
+	if len(s.Operations) == 0 {
+		return money.New(0, s.Currency)
+	}
+	return s.Operations[len(s.Operations)-1].BalanceNew
+}
+
+// Export writes statements to w while encoding, document itself isn't built in memory.
+// Statements are in memory already: history service sends whole history in one answer,
+// and operations must be grouped by card and sorted before opening balance is known
+func Export(w io.Writer, format Format, statements []Statement, now time.Time) error {
+	switch format {
+	case FormatCSV:
+		return exportCSV(w, statements)
+	case FormatOFX:
+		return exportOFX(w, statements, now)
+	case FormatCamt053:
+		return exportCamt053(w, statements, now)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// csvColumns are same for all statements, amounts are signed and use dot as decimal separator
+var csvColumns = []string{
+	"id", "date", "card", "type", "direction", "amount", "currency", "balance_before", "balance_after", "counterparty",
+}
+
+func exportCSV(w io.Writer, statements []Statement) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvColumns)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		for _, operation := range statement.Operations {
+			direction := "credit"
+			if operation.IsDebit() {
+				direction = "debit"
+			}
+			err = writer.Write([]string{
+				strconv.Itoa(operation.Id),
+				operation.Moment().Format(time.RFC3339),
+				csvText(operation.Number.String()),
+				csvText(operation.Name),
+				direction,
+				money.FormatAmount(operation.Signed(), money.LocaleISO),
+				string(statement.Currency),
+				money.FormatAmount(operation.BalanceOld, money.LocaleISO),
+				money.FormatAmount(operation.BalanceNew, money.LocaleISO),
+				csvText(operation.RecipientSender.String()),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText keeps spreadsheets from treating text as formula
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,87 @@
+package history
+
+import (
+	"bytes"
+	"flag"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// go test ./pkg/core/history -update rewrites golden files after intended format change
+var update = flag.Bool("update", false, "update golden files")
+
+func testStatements() []Statement {
+	allCards := []cards.Cards{
+		{Id: 7, Number: "4111111111111111", Currency: money.TJS},
+		{Id: 9, Number: "5555555555554444", Currency: money.USD},
+	}
+	operations := []ModelOperationsLog{
+		{Id: 1, Name: "transfer", Number: "4111111111111111", RecipientSender: "2200000000000004", Count: money.New(1500, ""), BalanceOld: money.New(101500, ""), BalanceNew: money.New(100000, ""), Time: 1760000000},
+		{Id: 2, Name: "receive", Number: "4111111111111111", RecipientSender: "5555555555554444", Count: money.New(2500, ""), BalanceOld: money.New(99000, ""), BalanceNew: money.New(101500, ""), Time: 1757000000},
+		{Id: 3, Name: "payment", Number: "5555555555554444", RecipientSender: "=HYPERLINK(\"http://evil\")", Count: money.New(100, ""), BalanceOld: money.New(5100, ""), BalanceNew: money.New(5000, ""), Time: 1759000000},
+		{Id: 4, Name: "+transfer", Number: "5555555555554444", Count: money.New(5, ""), BalanceOld: money.New(5000, ""), BalanceNew: money.New(5005, ""), Time: 1759500000},
+		{Id: 5, Name: "transfer", Number: "6011000990139424", Count: money.New(100, ""), BalanceOld: money.New(100, ""), BalanceNew: money.New(0, ""), Time: 1759500000},
+	}
+	filter := Filter{
+		From: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+	}
+	return NewStatements(operations, filter, allCards)
+}
+
+func TestExportGolden(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() {
+		time.Local = local
+	}()
+
+	now := time.Date(2025, 11, 2, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		format Format
+		golden string
+	}{
+		{FormatCSV, "statement.csv"},
+		{FormatOFX, "statement.ofx"},
+		{FormatCamt053, "statement.camt053.xml"},
+	}
+	for _, test := range tests {
+		buffer := &bytes.Buffer{}
+		err := Export(buffer, test.format, testStatements(), now)
+		if err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		path := filepath.Join("testdata", test.golden)
+		if *update {
+			err = ioutil.WriteFile(path, buffer.Bytes(), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buffer.Bytes(), want) {
+			t.Errorf("%s differs from %s:\n%s", test.format, path, buffer.Bytes())
+		}
+	}
+}
+
+func TestNewStatements(t *testing.T) {
+	statements := testStatements()
+	if len(statements) != 2 {
+		t.Fatalf("got %d statements, want 2 (operations of unknown card are skipped)", len(statements))
+	}
+	usd := statements[1]
+	if usd.AccountID != "9" || usd.Currency != money.USD {
+		t.Errorf("statement of card 9 = %s %s", usd.AccountID, usd.Currency)
+	}
+	if usd.Operations[0].Id != 3 || usd.Opening() != money.New(5100, money.USD) || usd.Closing() != money.New(5005, money.USD) {
+		t.Errorf("operations aren't ordered by time or balances are wrong: %v", usd.Operations)
+	}
+}
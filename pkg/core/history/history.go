@@ -6,8 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"time"
 )
@@ -15,19 +16,21 @@ import (
 type Url string
 
 type History struct {
-	url Url
+	url    Url
+	client *http.Client
 }
 
-func NewHistory(url Url) *History {
-	return &History{url: url}
+// NewHistory calls service with client, its transport forwards request id
+func NewHistory(url Url, client *http.Client) *History {
+	return &History{url: url, client: client}
 }
 
 var ErrUnknown = errors.New("unknown error")
 var ErrResponse = errors.New("response error")
 
 func (c *History) AllHistory(ctx context.Context, token string) (model []ModelOperationsLog, err error) {
-	log.Print("start func allhistory")
-	ctx, _ = context.WithTimeout(ctx, 6666*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 6666*time.Second)
+	defer cancel()
 	request, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodGet,
@@ -37,18 +40,13 @@ func (c *History) AllHistory(ctx context.Context, token string) (model []ModelOp
 	if err != nil {
 		return nil, fmt.Errorf("can't create request: %w", err)
 	}
-	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s",token))
-	response, err := http.DefaultClient.Do(request)
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	response, err := c.client.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("can't send request: %w", err)
 	}
 
-	defer func() {
-		err = response.Body.Close()
-		if err != nil {
-			log.Fatalf("can't close response body: %d", err)
-		}
-	}()
+	defer response.Body.Close()
 	err = ReadJSONBody2(response, &model)
 	if err != nil {
 		return nil, fmt.Errorf("can't parse response: %w", err)
@@ -56,8 +54,7 @@ func (c *History) AllHistory(ctx context.Context, token string) (model []ModelOp
 
 	switch response.StatusCode {
 	case 200:
-		log.Print("allhistory 200 ok")
-		return model,nil
+		return model, nil
 	case 400:
 
 		return nil, ErrResponse
@@ -86,13 +83,13 @@ func ReadJSONBody2(response *http.Response, dto interface{}) error {
 }
 
 type ModelOperationsLog struct {
-	Id              int    `json:"id"`
-	Name            string `json:"name"`
-	Number          string `json:"number"`
-	RecipientSender string `json:"recipientsender"`
-	Count           int64  `json:"count"`
-	BalanceOld      int64  `json:"balanceold"`
-	BalanceNew      int64  `json:"balancenew"`
-	Time            int64  `json:"time"`
-	OwnerID         int64  `json:"ownerid"`
+	Id              int          `json:"id"`
+	Name            string       `json:"name"`
+	Number          cards.Number `json:"number"`
+	RecipientSender cards.Number `json:"recipientsender"`
+	Count           money.Money  `json:"count"`
+	BalanceOld      money.Money  `json:"balanceold"`
+	BalanceNew      money.Money  `json:"balancenew"`
+	Time            int64        `json:"time"`
+	OwnerID         int64        `json:"ownerid"`
 }
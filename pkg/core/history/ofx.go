@@ -0,0 +1,140 @@
+package history
+
+import (
+	"encoding/xml"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io"
+	"strconv"
+	"time"
+)
+
+// OFX 2.1.1, each card is separate statement response
+const (
+	ofxHeader = `<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`
+	ofxTime   = "20060102150405"
+)
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+var ofxStatusOK = ofxStatus{Code: 0, Severity: "INFO"}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	Server   string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxAccount struct {
+	BankID string `xml:"BANKID"`
+	ID     string `xml:"ACCTID"`
+	Type   string `xml:"ACCTTYPE"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	ID     string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+func exportOFX(w io.Writer, statements []Statement, now time.Time) error {
+	_, err := io.WriteString(w, xml.Header+ofxHeader+"\n")
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	tags := &xmlTags{encoder: encoder}
+	tags.open("OFX")
+	tags.open("SIGNONMSGSRSV1")
+	tags.element("SONRS", ofxSignOn{Status: ofxStatusOK, Server: now.Format(ofxTime), Language: "RUS"})
+	tags.close()
+	tags.open("BANKMSGSRSV1")
+	for i, statement := range statements {
+		tags.open("STMTTRNRS")
+		tags.element("TRNUID", strconv.Itoa(i+1))
+		tags.element("STATUS", ofxStatusOK)
+		tags.open("STMTRS")
+		tags.element("CURDEF", string(statement.Currency))
+		tags.element("BANKACCTFROM", ofxAccount{BankID: "MYBANK", ID: statement.AccountID, Type: "CHECKING"})
+		tags.open("BANKTRANLIST")
+		tags.element("DTSTART", statement.From.Format(ofxTime))
+		tags.element("DTEND", statement.To.Format(ofxTime))
+		for _, operation := range statement.Operations {
+			transaction := ofxTransaction{
+				Type:   "CREDIT",
+				Posted: operation.Moment().Format(ofxTime),
+				Amount: money.FormatAmount(operation.Signed(), money.LocaleISO),
+				ID:     strconv.Itoa(operation.Id),
+				Name:   operation.Name,
+			}
+			if operation.IsDebit() {
+				transaction.Type = "DEBIT"
+			}
+			if operation.RecipientSender != "" {
+				transaction.Memo = operation.RecipientSender.String()
+			}
+			tags.element("STMTTRN", transaction)
+		}
+		tags.close()
+		tags.element("LEDGERBAL", ofxBalance{
+			Amount: money.FormatAmount(statement.Closing(), money.LocaleISO),
+			AsOf:   statement.To.Format(ofxTime),
+		})
+		tags.close()
+		tags.close()
+	}
+	tags.close()
+	tags.close()
+	return tags.flush()
+}
+
+// xmlTags streams nested elements and remembers first error
+type xmlTags struct {
+	encoder *xml.Encoder
+	stack   []xml.StartElement
+	err     error
+}
+
+func (t *xmlTags) open(name string, attrs ...xml.Attr) {
+	if t.err != nil {
+		return
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs}
+	t.stack = append(t.stack, start)
+	t.err = t.encoder.EncodeToken(start)
+}
+
+func (t *xmlTags) close() {
+	if t.err != nil {
+		return
+	}
+	start := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+	t.err = t.encoder.EncodeToken(start.End())
+}
+
+func (t *xmlTags) element(name string, value interface{}) {
+	if t.err != nil {
+		return
+	}
+	t.err = t.encoder.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+func (t *xmlTags) flush() error {
+	if t.err != nil {
+		return t.err
+	}
+	return t.encoder.Flush()
+}
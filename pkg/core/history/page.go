@@ -0,0 +1,187 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrBadCursor = errors.New("bad cursor")
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Query is filter with position in history, Cursor is Next of previous page.
+// Currencies of user cards are put to operations before they are filtered here
+type Query struct {
+	Filter
+	Cursor     string
+	Limit      int
+	Currencies map[cards.Number]money.Currency
+}
+
+// Page is part of history ordered from newest operation, Next is empty on last page
+type Page struct {
+	Operations []ModelOperationsLog `json:"items"`
+	Next       string               `json:"next_cursor"`
+}
+
+func (q Query) values() url.Values {
+	values := url.Values{}
+	if q.ID != 0 {
+		values.Set("id", strconv.Itoa(q.ID))
+	}
+	if !q.From.IsZero() {
+		values.Set("from", strconv.FormatInt(q.From.Unix(), 10))
+	}
+	if !q.To.IsZero() {
+		values.Set("to", strconv.FormatInt(q.To.Unix(), 10))
+	}
+	if q.CardID != 0 {
+		values.Set("card_id", strconv.Itoa(q.CardID))
+	}
+	if q.Direction != "" {
+		values.Set("direction", string(q.Direction))
+	}
+	if !q.MinAmount.IsZero() {
+		values.Set("min", wholeUnits(q.MinAmount))
+	}
+	if !q.MaxAmount.IsZero() {
+		values.Set("max", wholeUnits(q.MaxAmount))
+	}
+	if q.Counterparty != "" {
+		values.Set("counterparty", q.Counterparty)
+	}
+	if q.Cursor != "" {
+		values.Set("cursor", q.Cursor)
+	}
+	values.Set("limit", strconv.Itoa(q.Limit))
+	return values
+}
+
+// wholeUnits writes amount like it is sent in JSON, history service takes whole units as other services
+func wholeUnits(amount money.Money) string {
+	value, _ := amount.MarshalJSON()
+	return string(value)
+}
+
+// Page asks history service for one page of operations. Old history service ignores
+// query and answers with all operations, then filtering and paging is done here
+func (c *History) Page(ctx context.Context, query Query, token string) (Page, error) {
+	if query.Limit <= 0 || query.Limit > MaxLimit {
+		query.Limit = DefaultLimit
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("%s/api/history?%s", c.url, query.values().Encode()),
+		nil,
+	)
+	if err != nil {
+		return Page{}, fmt.Errorf("can't create request: %w", err)
+	}
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	response, err := c.client.Do(request)
+	if err != nil {
+		return Page{}, fmt.Errorf("can't send request: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return Page{}, fmt.Errorf("can't read response: %w", err)
+	}
+
+	switch response.StatusCode {
+	case 200:
+	case 400:
+		return Page{}, ErrResponse
+	default:
+		return Page{}, ErrUnknown
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var operations []ModelOperationsLog
+		err = json.Unmarshal(body, &operations)
+		if err != nil {
+			return Page{}, fmt.Errorf("can't parse response: %w", err)
+		}
+		// amounts of filter are in card currency, so operations get it before filtering
+		SetCurrencies(operations, query.Currencies)
+		return Paginate(operations, query)
+	}
+	var page Page
+	err = json.Unmarshal(body, &page)
+	if err != nil {
+		return Page{}, fmt.Errorf("can't parse response: %w", err)
+	}
+	SetCurrencies(page.Operations, query.Currencies)
+	return page, nil
+}
+
+// Paginate filters operations and cuts page after cursor
+func Paginate(operations []ModelOperationsLog, query Query) (Page, error) {
+	after, err := parseCursor(query.Cursor)
+	if err != nil {
+		return Page{}, err
+	}
+
+	matched := make([]ModelOperationsLog, 0)
+	for _, operation := range operations {
+		if query.Match(operation) && (after == nil || newer(*after, operation)) {
+			matched = append(matched, operation)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return newer(matched[i], matched[j])
+	})
+
+	page := Page{Operations: matched}
+	if len(matched) > query.Limit {
+		page.Operations = matched[:query.Limit]
+		last := page.Operations[query.Limit-1]
+		page.Next = fmt.Sprintf("%d.%d", last.Time, last.Id)
+	}
+	return page, nil
+}
+
+// newer orders operations by time and id, so operations with same time are not lost between pages
+func newer(a, b ModelOperationsLog) bool {
+	if a.Time != b.Time {
+		return a.Time > b.Time
+	}
+	return a.Id > b.Id
+}
+
+func parseCursor(cursor string) (*ModelOperationsLog, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: %s", ErrBadCursor, cursor)
+	}
+	moment, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadCursor, cursor)
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadCursor, cursor)
+	}
+	return &ModelOperationsLog{Id: id, Time: moment}, nil
+}
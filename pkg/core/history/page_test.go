@@ -0,0 +1,65 @@
+package history
+
+import (
+	"context"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestQueryValuesHideCardNumber(t *testing.T) {
+	query := Query{Filter: Filter{CardID: 7, Number: "4111111111111111"}, Limit: DefaultLimit}
+	encoded := query.values().Encode()
+	if strings.Contains(encoded, "4111111111111111") {
+		t.Errorf("card number is sent in query: %s", encoded)
+	}
+	if query.values().Get("card_id") != "7" {
+		t.Errorf("card_id = %q, want 7", query.values().Get("card_id"))
+	}
+}
+
+func TestQueryValuesAmountsInWholeUnits(t *testing.T) {
+	tests := []struct {
+		min  money.Money
+		max  money.Money
+		want string
+	}{
+		{money.New(1050, money.TJS), money.New(200000, money.TJS), "limit=20&max=2000&min=10.5"},
+		{money.New(1500, money.JPY), money.Money{}, "limit=20&min=1500"},
+		{money.Money{}, money.New(5, money.USD), "limit=20&max=0.05"},
+	}
+	for _, test := range tests {
+		query := Query{Filter: Filter{MinAmount: test.min, MaxAmount: test.max}, Limit: DefaultLimit}
+		got := query.values().Encode()
+		if got != test.want {
+			t.Errorf("values(%v, %v) = %s, want %s", test.min, test.max, got, test.want)
+		}
+	}
+}
+
+func TestPageFallbackSetsCurrencies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// old history service answers with all operations in whole units
+		_, _ = writer.Write([]byte(`[
+			{"id":1,"number":"4111111111111111","count":1500,"balanceold":5000,"balancenew":3500,"time":1760000000},
+			{"id":2,"number":"4111111111111111","count":3000,"balanceold":8000,"balancenew":5000,"time":1759000000}
+		]`))
+	}))
+	defer server.Close()
+
+	query := Query{
+		Filter:     Filter{Number: "4111111111111111", MaxAmount: money.New(2000, money.JPY)},
+		Limit:      DefaultLimit,
+		Currencies: map[cards.Number]money.Currency{"4111111111111111": money.JPY},
+	}
+	page, err := NewHistory(Url(server.URL), server.Client()).Page(context.Background(), query, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Operations) != 1 || page.Operations[0].Id != 1 || page.Operations[0].Count != money.New(1500, money.JPY) {
+		t.Errorf("operations = %+v, want only 1500 JPY", page.Operations)
+	}
+}
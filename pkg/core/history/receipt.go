@@ -0,0 +1,75 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/pdf"
+	"io"
+	"time"
+)
+
+var ErrOperationNotFound = errors.New("operation not found")
+
+// Operation finds operation of user by id. History service has no endpoint for single operation,
+// so id is asked through paged query and pages are read until operation is found
+func (c *History) Operation(ctx context.Context, id int, token string) (ModelOperationsLog, error) {
+	query := Query{Filter: Filter{ID: id}, Limit: MaxLimit}
+	for {
+		page, err := c.Page(ctx, query, token)
+		if err != nil {
+			return ModelOperationsLog{}, err
+		}
+		for _, operation := range page.Operations {
+			if operation.Id == id {
+				return operation, nil
+			}
+		}
+		if page.Next == "" {
+			return ModelOperationsLog{}, ErrOperationNotFound
+		}
+		query.Cursor = page.Next
+	}
+}
+
+// Receipt writes operation as one page PDF document, dates are in location of now
+func (o ModelOperationsLog) Receipt(w io.Writer, now time.Time) error {
+	document := pdf.New()
+	page := document.AddPage()
+
+	const left, value = 60.0, 220.0
+	y := pdf.PageHeight - 80
+	page.Text(left, y, pdf.Bold, 20, "My Bank")
+	y -= 28
+	page.Text(left, y, pdf.Regular, 14, fmt.Sprintf("Transaction receipt No. %d", o.Id))
+	y -= 16
+	page.Line(left, y, pdf.PageWidth-left, y)
+
+	rows := []struct {
+		label string
+		value string
+	}{
+		{"Operation ID", fmt.Sprint(o.Id)},
+		{"Operation type", o.Name},
+		{"Card", o.Number.String()},
+		{"Counterparty card", o.RecipientSender.String()},
+		{"Amount", o.Count.String()},
+		{"Balance before", o.BalanceOld.String()},
+		{"Balance after", o.BalanceNew.String()},
+		{"Date", o.Moment().In(now.Location()).Format("02.01.2006 15:04:05 MST")},
+	}
+	y -= 30
+	for _, row := range rows {
+		page.Text(left, y, pdf.Regular, 11, row.label)
+		page.Text(value, y, pdf.Bold, 11, row.value)
+		y -= 22
+	}
+
+	y -= 8
+	page.Line(left, y, pdf.PageWidth-left, y)
+	y -= 20
+	page.Text(left, y, pdf.Regular, 8, fmt.Sprintf("Issued %s. Card numbers are masked.", now.Format("02.01.2006 15:04:05 MST")))
+
+	_, err := document.WriteTo(w)
+	return err
+}
@@ -0,0 +1,51 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReceiptGolden(t *testing.T) {
+	// history service sends whole units, currency comes from card
+	var operations []ModelOperationsLog
+	err := json.Unmarshal([]byte(`[{"id":42,"name":"transfer","number":"4111111111111111","recipientsender":"5555555555554444","count":1500,"balanceold":101500,"balancenew":100000,"time":1760000000}]`), &operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetCurrencies(operations, map[cards.Number]money.Currency{"4111111111111111": money.JPY})
+
+	buffer := &bytes.Buffer{}
+	err = operations[0].Receipt(buffer, time.Date(2025, 11, 2, 8, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join("testdata", "receipt.pdf")
+	if *update {
+		err = ioutil.WriteFile(path, buffer.Bytes(), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), want) {
+		t.Errorf("receipt differs from %s:\n%s", path, buffer.Bytes())
+	}
+
+	for _, text := range []string{"(1 500 JPY)", "(101 500 JPY)", "(100 000 JPY)", "(09.10.2025 08:53:20 UTC)", "(Transaction receipt No. 42)"} {
+		if !bytes.Contains(buffer.Bytes(), []byte(text)) {
+			t.Errorf("receipt has no %q", text)
+		}
+	}
+	if bytes.Contains(buffer.Bytes(), []byte("4111111111111111")) {
+		t.Errorf("receipt has unmasked card number")
+	}
+}
@@ -0,0 +1,150 @@
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"sync"
+	"time"
+)
+
+var (
+	ErrPerTransaction = errors.New("per transaction limit exceeded")
+	ErrDaily          = errors.New("daily limit exceeded")
+	ErrMonthly        = errors.New("monthly limit exceeded")
+	ErrOnlineDisabled = errors.New("online payments disabled")
+	ErrNegative       = errors.New("limit can't be negative")
+)
+
+// Limits are spending controls of card set by owner, cards service knows nothing about them,
+// so they are checked by front. Amounts are in minor units of card currency, zero means no limit
+type Limits struct {
+	CardID         int       `json:"card_id"`
+	OwnerID        int       `json:"owner_id"`
+	PerTransaction int64     `json:"per_transaction"`
+	Daily          int64     `json:"daily"`
+	Monthly        int64     `json:"monthly"`
+	OnlineDisabled bool      `json:"online_disabled"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Spent is money already debited from card during current day and month
+type Spent struct {
+	Day   int64
+	Month int64
+}
+
+// Exceeded tells which limit is hit and how much can still be spent
+type Exceeded struct {
+	Limit money.Money
+	Left  money.Money
+	Err   error
+}
+
+func (e *Exceeded) Error() string {
+	return fmt.Sprintf("%v: limit %s, left %s", e.Err, e.Limit, e.Left)
+}
+
+// for errors.Is
+func (e *Exceeded) Unwrap() error {
+	return e.Err
+}
+
+// Code is used by pages to choose message
+func (e *Exceeded) Code() string {
+	switch e.Err {
+	case ErrPerTransaction:
+		return "limit.transaction"
+	case ErrDaily:
+		return "limit.daily"
+	case ErrMonthly:
+		return "limit.monthly"
+	default:
+		return "limit.online"
+	}
+}
+
+// Check returns *Exceeded when amount doesn't fit limits, online is true for payments to merchants
+func (l Limits) Check(amount money.Money, spent Spent, online bool) error {
+	currency := amount.Currency
+	if online && l.OnlineDisabled {
+		return &Exceeded{Limit: money.New(0, currency), Left: money.New(0, currency), Err: ErrOnlineDisabled}
+	}
+	if l.PerTransaction != 0 && amount.Amount > l.PerTransaction {
+		return &Exceeded{Limit: money.New(l.PerTransaction, currency), Left: money.New(l.PerTransaction, currency), Err: ErrPerTransaction}
+	}
+	if l.Daily != 0 && spent.Day+amount.Amount > l.Daily {
+		return &Exceeded{Limit: money.New(l.Daily, currency), Left: left(l.Daily, spent.Day, currency), Err: ErrDaily}
+	}
+	if l.Monthly != 0 && spent.Month+amount.Amount > l.Monthly {
+		return &Exceeded{Limit: money.New(l.Monthly, currency), Left: left(l.Monthly, spent.Month, currency), Err: ErrMonthly}
+	}
+	return nil
+}
+
+func left(limit int64, spent int64, currency money.Currency) money.Money {
+	if spent >= limit {
+		return money.New(0, currency)
+	}
+	return money.New(limit-spent, currency)
+}
+
+func (l Limits) IsZero() bool {
+	return l.PerTransaction == 0 && l.Daily == 0 && l.Monthly == 0 && !l.OnlineDisabled
+}
+
+type Store struct {
+	mutex sync.RWMutex
+	file  *storage.File
+	items []Limits
+}
+
+func NewStore(file *storage.File) (*Store, error) {
+	store := &Store{file: file}
+	err := file.Load(&store.items)
+	if err != nil {
+		return nil, fmt.Errorf("can't load limits: %w", err)
+	}
+	return store, nil
+}
+
+// Get returns limits of card, card without limits gets zero Limits
+func (s *Store) Get(cardID int) Limits {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, item := range s.items {
+		if item.CardID == cardID {
+			return item
+		}
+	}
+	return Limits{CardID: cardID}
+}
+
+// Set replaces limits of card, caller checks that card belongs to OwnerID
+func (s *Store) Set(limits Limits, now time.Time) (Limits, error) {
+	if limits.PerTransaction < 0 || limits.Daily < 0 || limits.Monthly < 0 {
+		return Limits{}, ErrNegative
+	}
+	limits.UpdatedAt = now
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items := make([]Limits, 0, len(s.items)+1)
+	for _, item := range s.items {
+		if item.CardID != limits.CardID {
+			items = append(items, item)
+		}
+	}
+	if !limits.IsZero() {
+		items = append(items, limits)
+	}
+	err := s.file.Save(items)
+	if err != nil {
+		return Limits{}, fmt.Errorf("can't save limits: %w", err)
+	}
+	s.items = items
+	return limits, nil
+}
@@ -0,0 +1,103 @@
+package limits
+
+import (
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	limits := Limits{CardID: 1, PerTransaction: 50000, Daily: 100000, Monthly: 300000}
+	tests := []struct {
+		name   string
+		limits Limits
+		amount int64
+		spent  Spent
+		online bool
+		err    error
+		left   int64
+	}{
+		{"no limits", Limits{CardID: 1}, 1000000, Spent{Day: 1000000, Month: 1000000}, true, nil, 0},
+		{"fits", limits, 50000, Spent{Day: 50000, Month: 250000}, false, nil, 0},
+		{"per transaction", limits, 50001, Spent{}, false, ErrPerTransaction, 50000},
+		{"daily", limits, 30000, Spent{Day: 80000, Month: 80000}, false, ErrDaily, 20000},
+		{"daily already spent over", limits, 100, Spent{Day: 120000, Month: 120000}, false, ErrDaily, 0},
+		{"monthly", limits, 30000, Spent{Day: 0, Month: 290000}, false, ErrMonthly, 10000},
+		{"monthly already spent over", limits, 100, Spent{Month: 350000}, false, ErrMonthly, 0},
+		{"online allowed", limits, 100, Spent{}, true, nil, 0},
+		{"online disabled", Limits{CardID: 1, OnlineDisabled: true}, 100, Spent{}, true, ErrOnlineDisabled, 0},
+		{"offline with online disabled", Limits{CardID: 1, OnlineDisabled: true}, 100, Spent{}, false, nil, 0},
+	}
+	for _, test := range tests {
+		err := test.limits.Check(money.New(test.amount, money.USD), test.spent, test.online)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if err == nil {
+			continue
+		}
+		var exceeded *Exceeded
+		if !errors.As(err, &exceeded) {
+			t.Errorf("%s: error %T isn't *Exceeded", test.name, err)
+			continue
+		}
+		if exceeded.Left != money.New(test.left, money.USD) {
+			t.Errorf("%s: left = %v, want %d", test.name, exceeded.Left, test.left)
+		}
+		if exceeded.Left.Amount < 0 {
+			t.Errorf("%s: left is negative", test.name)
+		}
+	}
+}
+
+func TestStoreSet(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		limits Limits
+		err    error
+		stored bool
+	}{
+		{"negative per transaction", Limits{CardID: 1, PerTransaction: -1}, ErrNegative, true},
+		{"negative daily", Limits{CardID: 1, Daily: -1}, ErrNegative, true},
+		{"negative monthly", Limits{CardID: 1, Monthly: -1}, ErrNegative, true},
+		{"changed", Limits{CardID: 1, Daily: 5000}, nil, true},
+		{"online only", Limits{CardID: 1, OnlineDisabled: true}, nil, true},
+		{"zero removes", Limits{CardID: 1}, nil, false},
+	}
+	for _, test := range tests {
+		store, err := NewStore(storage.NewFile(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.Set(Limits{CardID: 1, OwnerID: 1, Daily: 1000}, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.Set(Limits{CardID: 2, OwnerID: 1, Monthly: 1000}, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = store.Set(test.limits, now)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+		}
+		if got := len(store.items) == 2; got != test.stored {
+			t.Errorf("%s: items = %+v", test.name, store.items)
+		}
+		got := store.Get(1)
+		switch {
+		case err != nil && got.Daily != 1000:
+			t.Errorf("%s: limits changed after error: %+v", test.name, got)
+		case err == nil && (got.Daily != test.limits.Daily || got.OnlineDisabled != test.limits.OnlineDisabled):
+			t.Errorf("%s: Get() = %+v, want %+v", test.name, got, test.limits)
+		}
+		if store.Get(2).Monthly != 1000 {
+			t.Errorf("%s: other card changed: %+v", test.name, store.Get(2))
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Currency is ISO 4217 alphabetic code
+type Currency string
+
+const (
+	TJS Currency = "TJS"
+	RUB Currency = "RUB"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	KZT Currency = "KZT"
+	UZS Currency = "UZS"
+	JPY Currency = "JPY"
+)
+
+// DefaultCurrency used when upstream services send plain numbers
+var DefaultCurrency = TJS
+
+// number of digits after decimal separator (minor units)
+var exponents = map[Currency]int{
+	TJS: 2,
+	RUB: 2,
+	USD: 2,
+	EUR: 2,
+	KZT: 2,
+	UZS: 2,
+	JPY: 0,
+}
+
+var ErrInvalidAmount = errors.New("invalid amount")
+var ErrTooPrecise = errors.New("too many digits after decimal separator")
+var ErrOverflow = errors.New("amount overflow")
+var ErrUnknownCurrency = errors.New("unknown currency")
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := exponents[currency]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+	return currency, nil
+}
+
+func Currencies() []Currency {
+	return []Currency{TJS, RUB, USD, EUR, KZT, UZS, JPY}
+}
+
+func (c Currency) Exponent() int {
+	exponent, ok := exponents[c]
+	if !ok {
+		return 2
+	}
+	return exponent
+}
+
+// Money is amount in minor units (diram, kopeck, cent) with currency
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func New(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.currency() != other.currency() {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency(), other.currency())
+	}
+	sum := m.Amount + other.Amount
+	if (sum > m.Amount) != (other.Amount > 0) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: sum, Currency: m.currency()}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(other.Neg())
+}
+
+// Cmp returns -1, 0, +1 like strings.Compare
+func (m Money) Cmp(other Money) (int, error) {
+	if m.currency() != other.currency() {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency(), other.currency())
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (m Money) String() string {
+	return Format(m, DefaultLocale)
+}
+
+// WithCurrency sets currency keeping value in whole units. Amount decoded from JSON
+// before currency is known has exponent of default currency and is converted here
+func (m Money) WithCurrency(currency Currency) Money {
+	amount := m.Amount
+	for exponent := m.currency().Exponent(); exponent < currency.Exponent(); exponent++ {
+		amount *= 10
+	}
+	for exponent := m.currency().Exponent(); exponent > currency.Exponent(); exponent-- {
+		amount /= 10
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Upstream contract: cards, history, chat and payments services send and take amounts
+// as JSON numbers in whole units (10 or 10.5), like transfer form did before money type.
+// Currency goes in separate field, see WithCurrency.
+func (m Money) MarshalJSON() ([]byte, error) {
+	value := FormatAmount(m, LocaleISO)
+	if strings.ContainsRune(value, '.') {
+		value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+	}
+	return []byte(value), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	err := json.Unmarshal(data, &number)
+	if err != nil {
+		return fmt.Errorf("can't decode money %s: %w", data, err)
+	}
+	parsed, err := Parse(number.String(), m.currency(), LocaleISO)
+	if err != nil {
+		return fmt.Errorf("can't decode money %s: %w", data, err)
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) currency() Currency {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// Locale describes separators used by users when typing amounts
+type Locale struct {
+	Decimal rune
+	Group   rune
+}
+
+var (
+	LocaleEN = Locale{Decimal: '.', Group: ','}
+	LocaleRU = Locale{Decimal: ',', Group: ' '}
+	// LocaleISO has no grouping and is used in files for other programs: "1234.50"
+	LocaleISO = Locale{Decimal: '.'}
+)
+
+// DefaultLocale matches language of our web pages
+var DefaultLocale = LocaleRU
+
+func LocaleByName(name string) Locale {
+	switch strings.ToLower(name) {
+	case "en", "en-us", "en-gb":
+		return LocaleEN
+	default:
+		return DefaultLocale
+	}
+}
+
+// Parse reads amount typed by user: "10", "10,5", "1 234,50", "1,234.50" (for LocaleEN).
+// If input contains only one separator which is not locale decimal, it is treated as decimal too,
+// so "10.50" works for LocaleRU. Group separators must split whole part by three digits,
+// so "10,50" is rejected for LocaleEN instead of becoming 1050.
+func Parse(input string, currency Currency, locale Locale) (Money, error) {
+	if _, ok := exponents[currency]; !ok {
+		return Money{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+
+	value := strings.TrimSpace(input)
+	value = strings.TrimSuffix(value, string(currency))
+	value = strings.TrimSpace(value)
+
+	negative := false
+	if strings.HasPrefix(value, "-") {
+		negative = true
+		value = value[1:]
+	} else if strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+
+	decimal := locale.Decimal
+	if !strings.ContainsRune(value, decimal) {
+		for _, candidate := range []rune{'.', ','} {
+			if candidate != locale.Group && strings.Count(value, string(candidate)) == 1 {
+				decimal = candidate
+			}
+		}
+	}
+
+	whole, fraction := value, ""
+	if index := strings.IndexRune(value, decimal); index >= 0 {
+		whole, fraction = value[:index], value[index+len(string(decimal)):]
+	}
+	whole, ok := ungroup(whole, locale)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+	}
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+	}
+
+	exponent := currency.Exponent()
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%w: %q", ErrTooPrecise, input)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, input)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// FormatAmount returns amount without currency: "1 234,50"
+func FormatAmount(m Money, locale Locale) string {
+	exponent := m.currency().Exponent()
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	var absolute uint64
+	if amount == math.MinInt64 {
+		absolute = uint64(math.MaxInt64) + 1
+	} else if amount < 0 {
+		absolute = uint64(-amount)
+	} else {
+		absolute = uint64(amount)
+	}
+
+	digits := strconv.FormatUint(absolute, 10)
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-exponent], digits[len(digits)-exponent:]
+
+	builder := strings.Builder{}
+	builder.WriteString(sign)
+	for i, digit := range whole {
+		if locale.Group != 0 && i > 0 && (len(whole)-i)%3 == 0 {
+			builder.WriteRune(locale.Group)
+		}
+		builder.WriteRune(digit)
+	}
+	if exponent > 0 {
+		builder.WriteRune(locale.Decimal)
+		builder.WriteString(fraction)
+	}
+	return builder.String()
+}
+
+// Format returns amount with currency code: "1 234,50 TJS"
+func Format(m Money, locale Locale) string {
+	return fmt.Sprintf("%s %s", FormatAmount(m, locale), m.currency())
+}
+
+func isGroupSeparator(r rune, locale Locale) bool {
+	return r == locale.Group || r == ' ' || r == '\u00a0' || r == '\u202f' || r == '\''
+}
+
+// ungroup removes group separators: "1 234 567" -> "1234567", false for "10,50" or "1,,234"
+func ungroup(whole string, locale Locale) (string, bool) {
+	var groups []string
+	start := 0
+	for i, r := range whole {
+		if isGroupSeparator(r, locale) {
+			groups = append(groups, whole[start:i])
+			start = i + utf8.RuneLen(r)
+		}
+	}
+	if groups == nil {
+		return whole, true
+	}
+	groups = append(groups, whole[start:])
+	for i, group := range groups {
+		if (i == 0 && (group == "" || len(group) > 3)) || (i > 0 && len(group) != 3) {
+			return "", false
+		}
+	}
+	return strings.Join(groups, ""), true
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency Currency
+		locale   Locale
+		want     int64
+		err      error
+	}{
+		{"10", TJS, LocaleRU, 1000, nil},
+		{"10,5", TJS, LocaleRU, 1050, nil},
+		{"10.50", TJS, LocaleRU, 1050, nil},
+		{"1 234,50", TJS, LocaleRU, 123450, nil},
+		{"1 234,50 TJS", TJS, LocaleRU, 123450, nil},
+		{"-0,01", TJS, LocaleRU, -1, nil},
+		{",5", TJS, LocaleRU, 50, nil},
+		{"1,234.50", USD, LocaleEN, 123450, nil},
+		{"1,234,567", USD, LocaleEN, 123456700, nil},
+		{"10.5", USD, LocaleEN, 1050, nil},
+		{"1234.5", USD, LocaleISO, 123450, nil},
+		{"500", JPY, LocaleEN, 500, nil},
+		{"10,50", USD, LocaleEN, 0, ErrInvalidAmount},
+		{"1,23,456", USD, LocaleEN, 0, ErrInvalidAmount},
+		{"1,,234", USD, LocaleEN, 0, ErrInvalidAmount},
+		{",234", USD, LocaleEN, 0, ErrInvalidAmount},
+		{"12 34", TJS, LocaleRU, 0, ErrInvalidAmount},
+		{"", TJS, LocaleRU, 0, ErrInvalidAmount},
+		{"abc", TJS, LocaleRU, 0, ErrInvalidAmount},
+		{"1.234,50", TJS, LocaleRU, 0, ErrInvalidAmount},
+		{"10,555", TJS, LocaleRU, 0, ErrTooPrecise},
+		{"0.5", JPY, LocaleEN, 0, ErrTooPrecise},
+		{"99999999999999999999", TJS, LocaleRU, 0, ErrOverflow},
+		{"10", "XXX", LocaleRU, 0, ErrUnknownCurrency},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.input, test.currency, test.locale)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("Parse(%q) error = %v, want %v", test.input, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", test.input, err)
+			continue
+		}
+		if got.Amount != test.want || got.Currency != test.currency {
+			t.Errorf("Parse(%q) = %d %s, want %d %s", test.input, got.Amount, got.Currency, test.want, test.currency)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		money  Money
+		locale Locale
+		want   string
+	}{
+		{New(123450, TJS), LocaleRU, "1 234,50 TJS"},
+		{New(123456700, USD), LocaleEN, "1,234,567.00 USD"},
+		{New(5, TJS), LocaleRU, "0,05 TJS"},
+		{New(-1050, RUB), LocaleRU, "-10,50 RUB"},
+		{New(1234, JPY), LocaleEN, "1,234 JPY"},
+		{New(123450, USD), LocaleISO, "1234.50 USD"},
+		{Money{Amount: 100}, LocaleRU, "1,00 TJS"},
+	}
+	for _, test := range tests {
+		got := Format(test.money, test.locale)
+		if got != test.want {
+			t.Errorf("Format(%d %s) = %q, want %q", test.money.Amount, test.money.Currency, got, test.want)
+		}
+	}
+}
+
+func TestParseFormatRoundTrip(t *testing.T) {
+	for _, locale := range []Locale{LocaleRU, LocaleEN, LocaleISO} {
+		for _, amount := range []int64{0, 1, 99, 100, 123456789, -4200} {
+			value := New(amount, EUR)
+			got, err := Parse(FormatAmount(value, locale), EUR, locale)
+			if err != nil || got != value {
+				t.Errorf("Parse(FormatAmount(%d)) = %v, %v", amount, got, err)
+			}
+		}
+	}
+}
+
+func TestJSONWholeUnits(t *testing.T) {
+	tests := []struct {
+		money Money
+		json  string
+	}{
+		{New(1000, TJS), "10"},
+		{New(1050, TJS), "10.5"},
+		{New(-1, TJS), "-0.01"},
+		{New(0, TJS), "0"},
+		{New(500, JPY), "500"},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(test.money)
+		if err != nil || string(data) != test.json {
+			t.Errorf("Marshal(%d %s) = %s, %v, want %s", test.money.Amount, test.money.Currency, data, err, test.json)
+		}
+	}
+
+	var card struct {
+		Balance Money `json:"balance"`
+	}
+	err := json.Unmarshal([]byte(`{"balance": 10.5}`), &card)
+	if err != nil || card.Balance != New(1050, DefaultCurrency) {
+		t.Errorf("Unmarshal = %v, %v", card.Balance, err)
+	}
+	err = json.Unmarshal([]byte(`{"balance": 1e3}`), &card)
+	if err == nil {
+		t.Errorf("Unmarshal of exponent amount must fail")
+	}
+}
+
+func TestWithCurrency(t *testing.T) {
+	var decoded Money
+	err := json.Unmarshal([]byte("500"), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := decoded.WithCurrency(JPY)
+	if got != New(500, JPY) {
+		t.Errorf("WithCurrency(JPY) = %d %s, want 500 JPY", got.Amount, got.Currency)
+	}
+	got = decoded.WithCurrency(USD)
+	if got != New(50000, USD) {
+		t.Errorf("WithCurrency(USD) = %d %s, want 50000 USD", got.Amount, got.Currency)
+	}
+	got = New(500, JPY).WithCurrency(USD)
+	if got != New(50000, USD) {
+		t.Errorf("JPY WithCurrency(USD) = %d %s, want 50000 USD", got.Amount, got.Currency)
+	}
+}
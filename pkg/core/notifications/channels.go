@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"github.com/jafarsirojov/bank-front/pkg/notify"
+	"time"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+var ErrNoAddress = errors.New("user has no address for channel")
+
+// Channel delivers notification outside of app. Channel is used if user enabled it in settings,
+// mandatory notifications go to every channel user has address for
+type Channel interface {
+	Name() string
+	Deliver(ctx context.Context, notification Notification, settings Settings) error
+}
+
+// Outbound renders notification with template of its kind and puts it to queue of channel
+type Outbound struct {
+	name      string
+	queue     *notify.Queue
+	templates *notify.Templates
+	baseURL   string
+}
+
+func NewOutbound(name string, queue *notify.Queue, templates *notify.Templates, baseURL string) *Outbound {
+	return &Outbound{name: name, queue: queue, templates: templates, baseURL: baseURL}
+}
+
+func (o *Outbound) Name() string {
+	return o.name
+}
+
+// templateData is what templates get: notification with its data and absolute link
+type templateData struct {
+	Notification
+	URL string
+}
+
+func (o *Outbound) Deliver(ctx context.Context, notification Notification, settings Settings) error {
+	to := settings.Address(o.name)
+	if to == "" {
+		return ErrNoAddress
+	}
+	if !o.templates.Has(string(notification.Kind), o.name) {
+		// kind isn't sent by this channel, e.g. chat messages by sms
+		return nil
+	}
+	data := templateData{Notification: notification}
+	if notification.Link != "" {
+		data.URL = o.baseURL + notification.Link
+	}
+	message, err := o.templates.Render(string(notification.Kind), o.name, data)
+	if err != nil {
+		return err
+	}
+	message.To = to
+	return o.queue.Enqueue(o.name, message)
+}
+
+// Center stores notification for app and delivers it to other channels
+type Center struct {
+	store    *Store
+	channels []Channel
+}
+
+func NewCenter(store *Store, channels ...Channel) *Center {
+	return &Center{store: store, channels: channels}
+}
+
+func (c *Center) Store() *Store {
+	return c.store
+}
+
+// Channels returns names of channels user may enable
+func (c *Center) Channels() []string {
+	names := make([]string, 0, len(c.channels))
+	for _, channel := range c.channels {
+		names = append(names, channel.Name())
+	}
+	return names
+}
+
+// Notify doesn't fail operation which caused notification, errors are only logged
+func (c *Center) Notify(ctx context.Context, notification Notification) {
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+	notification, err := c.store.Add(notification)
+	if err != nil {
+		logging.Errorf(ctx, "can't store notification for %d: %v", notification.UserID, err)
+	}
+
+	settings := c.store.Settings(notification.UserID)
+	for _, channel := range c.channels {
+		mandatory := notification.Kind.Mandatory() && settings.Address(channel.Name()) != ""
+		if !mandatory && !settings.Enabled(channel.Name()) {
+			continue
+		}
+		err := channel.Deliver(context.Background(), notification, settings)
+		if err != nil {
+			logging.Errorf(ctx, "can't deliver notification %d by %s: %v", notification.ID, channel.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("notification not found")
+	ErrBadEmail = errors.New("bad email")
+	ErrBadPhone = errors.New("bad phone")
+)
+
+// international number without spaces, e.g. +992900000000
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+// MaxPerUser is how many notifications are kept for one user, older ones are removed
+const MaxPerUser = 200
+
+type Kind string
+
+const (
+	KindMoneyReceived Kind = "money_received"
+	KindMoneySent     Kind = "money_sent"
+	KindCardBlocked   Kind = "card_blocked"
+	KindCardUnblocked Kind = "card_unblocked"
+	KindMessage       Kind = "chat_message"
+	KindNewDevice     Kind = "new_device"
+)
+
+// Mandatory notifications are required by compliance to be sent out of app,
+// user can't turn them off, only remove address
+func (k Kind) Mandatory() bool {
+	return k == KindMoneySent || k == KindNewDevice
+}
+
+type Notification struct {
+	ID        int64             `json:"id"`
+	UserID    int               `json:"user_id"`
+	Kind      Kind              `json:"kind"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Link      string            `json:"link"`
+	Data      map[string]string `json:"data,omitempty"`
+	Read      bool              `json:"read"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Settings are chosen by user on notifications page, in-app notifications can't be turned off
+type Settings struct {
+	Email    string          `json:"email"`
+	Phone    string          `json:"phone"`
+	Channels map[string]bool `json:"channels"`
+}
+
+func (s Settings) Enabled(channel string) bool {
+	return s.Channels[channel]
+}
+
+// Address returns where channel sends messages to user
+func (s Settings) Address(channel string) string {
+	switch channel {
+	case ChannelEmail:
+		return s.Email
+	case ChannelSMS:
+		return s.Phone
+	default:
+		return ""
+	}
+}
+
+// Store keeps notifications shown in app and settings of users
+type Store struct {
+	mutex sync.RWMutex
+	file  *storage.File
+	data  storeData
+}
+
+type storeData struct {
+	NextID   int64            `json:"next_id"`
+	Items    []Notification   `json:"items"`
+	Settings map[int]Settings `json:"settings"`
+}
+
+func NewStore(file *storage.File) (*Store, error) {
+	store := &Store{file: file}
+	err := file.Load(&store.data)
+	if err != nil {
+		return nil, fmt.Errorf("can't load notifications: %w", err)
+	}
+	if store.data.Settings == nil {
+		store.data.Settings = make(map[int]Settings)
+	}
+	return store, nil
+}
+
+func (s *Store) Add(notification Notification) (Notification, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data.NextID++
+	notification.ID = s.data.NextID
+	notification.Read = false
+	s.data.Items = append(s.data.Items, notification)
+	s.trim(notification.UserID)
+	return notification, s.save()
+}
+
+// trim removes oldest notifications of user above MaxPerUser, items are in order of adding
+func (s *Store) trim(userID int) {
+	count := 0
+	for _, item := range s.data.Items {
+		if item.UserID == userID {
+			count++
+		}
+	}
+	if count <= MaxPerUser {
+		return
+	}
+	items := s.data.Items[:0]
+	for _, item := range s.data.Items {
+		if item.UserID == userID && count > MaxPerUser {
+			count--
+			continue
+		}
+		items = append(items, item)
+	}
+	s.data.Items = items
+}
+
+// List returns notifications of user, newest first
+func (s *Store) List(userID int) []Notification {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]Notification, 0)
+	for _, item := range s.data.Items {
+		if item.UserID == userID {
+			result = append(result, item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+	return result
+}
+
+func (s *Store) Unread(userID int) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	count := 0
+	for _, item := range s.data.Items {
+		if item.UserID == userID && !item.Read {
+			count++
+		}
+	}
+	return count
+}
+
+// MarkRead marks notification of user as read and returns it, e.g. to follow its link
+func (s *Store) MarkRead(userID int, id int64) (Notification, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.data.Items {
+		item := &s.data.Items[i]
+		if item.ID == id && item.UserID == userID {
+			if item.Read {
+				return *item, nil
+			}
+			item.Read = true
+			return *item, s.save()
+		}
+	}
+	return Notification{}, ErrNotFound
+}
+
+func (s *Store) MarkAllRead(userID int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changed := false
+	for i := range s.data.Items {
+		item := &s.data.Items[i]
+		if item.UserID == userID && !item.Read {
+			item.Read = true
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *Store) Settings(userID int) Settings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.data.Settings[userID]
+}
+
+// SaveSettings checks email, channel which needs email can't be enabled without it
+func (s *Store) SaveSettings(userID int, settings Settings) error {
+	settings.Email = strings.TrimSpace(settings.Email)
+	if settings.Email != "" {
+		address, err := mail.ParseAddress(settings.Email)
+		if err != nil || address.Name != "" || address.Address != settings.Email {
+			return ErrBadEmail
+		}
+	}
+	if settings.Email == "" && settings.Enabled(ChannelEmail) {
+		return ErrBadEmail
+	}
+	settings.Phone = strings.TrimSpace(settings.Phone)
+	if settings.Phone != "" && !phonePattern.MatchString(settings.Phone) {
+		return ErrBadPhone
+	}
+	if settings.Phone == "" && settings.Enabled(ChannelSMS) {
+		return ErrBadPhone
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data.Settings[userID] = settings
+	return s.save()
+}
+
+// DefaultPhone sets phone known from token of user, if user didn't enter it
+func (s *Store) DefaultPhone(userID int, phone string) error {
+	if !phonePattern.MatchString(phone) {
+		return ErrBadPhone
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	settings := s.data.Settings[userID]
+	if settings.Phone != "" {
+		return nil
+	}
+	settings.Phone = phone
+	s.data.Settings[userID] = settings
+	return s.save()
+}
+
+func (s *Store) save() error {
+	err := s.file.Save(s.data)
+	if err != nil {
+		return fmt.Errorf("can't save notifications: %w", err)
+	}
+	return nil
+}
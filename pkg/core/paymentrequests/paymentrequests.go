@@ -0,0 +1,304 @@
+package paymentrequests
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrNotFound     = errors.New("payment request not found")
+	ErrNotPending   = errors.New("payment request is not pending")
+	ErrExpired      = errors.New("payment request is expired")
+	ErrSelf         = errors.New("can't request money from yourself")
+	ErrLongNote     = errors.New("note is too long")
+	ErrExpiryInPast = errors.New("expiry is in the past")
+)
+
+const MaxNoteLength = 140
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusPaying    Status = "paying"
+	StatusPaid      Status = "paid"
+	StatusDeclined  Status = "declined"
+	StatusCancelled Status = "cancelled"
+	StatusExpired   Status = "expired"
+)
+
+// Request asks payer to send money to card of requester. Request without payer is shared
+// by link and may be paid by any user who knows Token
+type Request struct {
+	Id          int64        `json:"id"`
+	RequesterID int          `json:"requester_id"`
+	PayerID     int          `json:"payer_id"`
+	Link        bool         `json:"link"`
+	Token       string       `json:"token"`
+	Number      cards.Number `json:"number"`
+	Amount      money.Money  `json:"amount"`
+	Currency    string       `json:"currency"`
+	Note        string       `json:"note"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	Status      Status       `json:"status"`
+	PaidBy      int          `json:"paid_by"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// restore currency of amount, it is not part of money json
+func (r *Request) normalize() {
+	r.Amount = r.Amount.WithCurrency(money.Currency(r.Currency))
+}
+
+// State is status shown to users, pending request becomes expired without writing to store
+func (r Request) State(now time.Time) Status {
+	if r.Status == StatusPending && !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt) {
+		return StatusExpired
+	}
+	return r.Status
+}
+
+// PayableBy tells if user may pay or decline request
+func (r Request) PayableBy(userID int) bool {
+	if r.RequesterID == userID {
+		return false
+	}
+	return r.Link || r.PayerID == userID
+}
+
+type Store struct {
+	mutex sync.RWMutex
+	file  *storage.File
+	data  storeData
+}
+
+type storeData struct {
+	NextID int64     `json:"next_id"`
+	Items  []Request `json:"items"`
+}
+
+func NewStore(file *storage.File) (*Store, error) {
+	store := &Store{file: file}
+	err := file.Load(&store.data)
+	if err != nil {
+		return nil, fmt.Errorf("can't load payment requests: %w", err)
+	}
+	for i := range store.data.Items {
+		store.data.Items[i].normalize()
+	}
+	return store, nil
+}
+
+// Create validates request and gives it token for link
+func (s *Store) Create(request Request, now time.Time) (Request, error) {
+	if !request.Amount.IsPositive() {
+		return Request{}, money.ErrInvalidAmount
+	}
+	if !request.Link && request.PayerID == request.RequesterID {
+		return Request{}, ErrSelf
+	}
+	request.Note = strings.TrimSpace(request.Note)
+	if utf8.RuneCountInString(request.Note) > MaxNoteLength {
+		return Request{}, ErrLongNote
+	}
+	if !request.ExpiresAt.IsZero() && !request.ExpiresAt.After(now) {
+		return Request{}, ErrExpiryInPast
+	}
+	if request.Link {
+		request.PayerID = 0
+	}
+	token := make([]byte, 16)
+	_, err := rand.Read(token)
+	if err != nil {
+		return Request{}, fmt.Errorf("can't generate token: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data := s.copyData()
+	data.NextID++
+	request.Id = data.NextID
+	request.Token = hex.EncodeToString(token)
+	request.Currency = string(request.Amount.Currency)
+	request.Status = StatusPending
+	request.CreatedAt = now
+	request.UpdatedAt = now
+	data.Items = append(data.Items, request)
+	err = s.save(data)
+	if err != nil {
+		return Request{}, err
+	}
+	return request, nil
+}
+
+func (s *Store) Get(id int64) (Request, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, item := range s.data.Items {
+		if item.Id == id {
+			return item, nil
+		}
+	}
+	return Request{}, ErrNotFound
+}
+
+func (s *Store) ByToken(token string) (Request, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, item := range s.data.Items {
+		if token != "" && item.Token == token {
+			return item, nil
+		}
+	}
+	return Request{}, ErrNotFound
+}
+
+// Incoming returns requests addressed to payer waiting for payment, newest first
+func (s *Store) Incoming(payerID int, now time.Time) []Request {
+	return s.list(func(item Request) bool {
+		return !item.Link && item.PayerID == payerID && item.State(now) == StatusPending
+	})
+}
+
+// Outgoing returns all requests made by requester, newest first
+func (s *Store) Outgoing(requesterID int) []Request {
+	return s.list(func(item Request) bool {
+		return item.RequesterID == requesterID
+	})
+}
+
+func (s *Store) list(match func(item Request) bool) []Request {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]Request, 0)
+	for _, item := range s.data.Items {
+		if match(item) {
+			result = append(result, item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id > result[j].Id
+	})
+	return result
+}
+
+// Begin locks pending request for payment by payer, so second click can't pay it twice.
+// Finish is called when result of transfer is known, request stays paying otherwise
+func (s *Store) Begin(id int64, payerID int, now time.Time) (Request, error) {
+	return s.update(id, func(item *Request) error {
+		if !item.PayableBy(payerID) {
+			return ErrNotFound
+		}
+		switch item.State(now) {
+		case StatusPending:
+		case StatusExpired:
+			return ErrExpired
+		default:
+			return ErrNotPending
+		}
+		item.Status = StatusPaying
+		item.PaidBy = payerID
+		item.UpdatedAt = now
+		return nil
+	})
+}
+
+// Finish marks request paid or returns it to pending when transfer failed
+func (s *Store) Finish(id int64, paid bool, now time.Time) (Request, error) {
+	return s.update(id, func(item *Request) error {
+		if item.Status != StatusPaying {
+			return ErrNotPending
+		}
+		item.Status = StatusPaid
+		if !paid {
+			item.Status = StatusPending
+			item.PaidBy = 0
+		}
+		item.UpdatedAt = now
+		return nil
+	})
+}
+
+// Decline is done by payer of addressed request
+func (s *Store) Decline(id int64, payerID int, now time.Time) (Request, error) {
+	return s.update(id, func(item *Request) error {
+		if item.Link || item.PayerID != payerID || item.RequesterID == payerID {
+			return ErrNotFound
+		}
+		if item.State(now) != StatusPending {
+			return ErrNotPending
+		}
+		item.Status = StatusDeclined
+		item.UpdatedAt = now
+		return nil
+	})
+}
+
+// Cancel is done by requester
+func (s *Store) Cancel(id int64, requesterID int, now time.Time) (Request, error) {
+	return s.update(id, func(item *Request) error {
+		if item.RequesterID != requesterID {
+			return ErrNotFound
+		}
+		if item.State(now) != StatusPending {
+			return ErrNotPending
+		}
+		item.Status = StatusCancelled
+		item.UpdatedAt = now
+		return nil
+	})
+}
+
+func (s *Store) update(id int64, change func(item *Request) error) (Request, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.data.Items {
+		if s.data.Items[i].Id != id {
+			continue
+		}
+		item := s.data.Items[i]
+		err := change(&item)
+		if err != nil {
+			return Request{}, err
+		}
+		data := s.copyData()
+		data.Items[i] = item
+		err = s.save(data)
+		if err != nil {
+			return Request{}, err
+		}
+		return item, nil
+	}
+	return Request{}, ErrNotFound
+}
+
+// copyData returns data which can be changed without touching store
+func (s *Store) copyData() storeData {
+	return storeData{NextID: s.data.NextID, Items: append([]Request(nil), s.data.Items...)}
+}
+
+// save writes data and only then swaps it in, so memory doesn't differ from file on error
+func (s *Store) save(data storeData) error {
+	err := s.file.Save(data)
+	if err != nil {
+		return fmt.Errorf("can't save payment requests: %w", err)
+	}
+	s.data = data
+	return nil
+}
@@ -0,0 +1,209 @@
+package paymentrequests
+
+import (
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T) *Store {
+	store, err := NewStore(storage.NewFile(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func create(t *testing.T, store *Store, request Request) Request {
+	if request.Amount.IsZero() {
+		request.Amount = money.New(1000, money.TJS)
+	}
+	created, err := store.Create(request, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+func TestCreate(t *testing.T) {
+	store := newTestStore(t)
+	tests := []struct {
+		name    string
+		request Request
+		err     error
+	}{
+		{"zero amount", Request{RequesterID: 1, PayerID: 2, Amount: money.New(0, money.TJS)}, money.ErrInvalidAmount},
+		{"from yourself", Request{RequesterID: 1, PayerID: 1, Amount: money.New(100, money.TJS)}, ErrSelf},
+		{"expired", Request{RequesterID: 1, PayerID: 2, Amount: money.New(100, money.TJS), ExpiresAt: now}, ErrExpiryInPast},
+		{"addressed", Request{RequesterID: 1, PayerID: 2, Amount: money.New(100, money.TJS)}, nil},
+		{"link", Request{RequesterID: 1, PayerID: 5, Link: true, Amount: money.New(100, money.TJS)}, nil},
+	}
+	for _, test := range tests {
+		got, err := store.Create(test.request, now)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.Status != StatusPending || len(got.Token) != 32 || got.Currency != string(money.TJS) {
+			t.Errorf("%s: created %+v", test.name, got)
+		}
+		if got.Link && got.PayerID != 0 {
+			t.Errorf("%s: link request keeps payer %d", test.name, got.PayerID)
+		}
+	}
+}
+
+func TestPaidFlow(t *testing.T) {
+	store := newTestStore(t)
+	request := create(t, store, Request{RequesterID: 1, PayerID: 2})
+
+	_, err := store.Begin(request.Id, 3, now)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Begin() by stranger error = %v, want ErrNotFound", err)
+	}
+	paying, err := store.Begin(request.Id, 2, now)
+	if err != nil || paying.Status != StatusPaying || paying.PaidBy != 2 {
+		t.Fatalf("Begin() = %+v, %v", paying, err)
+	}
+	_, err = store.Begin(request.Id, 2, now)
+	if !errors.Is(err, ErrNotPending) {
+		t.Errorf("second Begin() error = %v, want ErrNotPending", err)
+	}
+	paid, err := store.Finish(request.Id, true, now)
+	if err != nil || paid.Status != StatusPaid {
+		t.Fatalf("Finish(true) = %+v, %v", paid, err)
+	}
+	_, err = store.Finish(request.Id, true, now)
+	if !errors.Is(err, ErrNotPending) {
+		t.Errorf("second Finish() error = %v, want ErrNotPending", err)
+	}
+}
+
+func TestRejectedTransferReturnsToPending(t *testing.T) {
+	store := newTestStore(t)
+	request := create(t, store, Request{RequesterID: 1, Link: true})
+
+	_, err := store.Begin(request.Id, 2, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := store.Finish(request.Id, false, now)
+	if err != nil || pending.Status != StatusPending || pending.PaidBy != 0 {
+		t.Fatalf("Finish(false) = %+v, %v", pending, err)
+	}
+	_, err = store.Begin(request.Id, 3, now)
+	if err != nil {
+		t.Errorf("Begin() after rejected transfer error = %v", err)
+	}
+}
+
+func TestUnknownTransferStaysPaying(t *testing.T) {
+	store := newTestStore(t)
+	request := create(t, store, Request{RequesterID: 1, PayerID: 2})
+
+	_, err := store.Begin(request.Id, 2, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// result of transfer is unknown, Finish isn't called
+	later := now.Add(time.Hour)
+	got, err := store.Get(request.Id)
+	if err != nil || got.State(later) != StatusPaying {
+		t.Errorf("state = %s, %v, want paying", got.State(later), err)
+	}
+	for _, change := range []func() (Request, error){
+		func() (Request, error) { return store.Begin(request.Id, 2, later) },
+		func() (Request, error) { return store.Decline(request.Id, 2, later) },
+		func() (Request, error) { return store.Cancel(request.Id, 1, later) },
+	} {
+		_, err = change()
+		if !errors.Is(err, ErrNotPending) {
+			t.Errorf("change of paying request error = %v, want ErrNotPending", err)
+		}
+	}
+	if len(store.Incoming(2, later)) != 0 {
+		t.Errorf("paying request is shown as incoming")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	store := newTestStore(t)
+	request := create(t, store, Request{RequesterID: 1, PayerID: 2, ExpiresAt: now.Add(time.Hour)})
+
+	later := now.Add(time.Hour)
+	if request.State(later) != StatusExpired {
+		t.Errorf("state = %s, want expired", request.State(later))
+	}
+	_, err := store.Begin(request.Id, 2, later)
+	if !errors.Is(err, ErrExpired) {
+		t.Errorf("Begin() error = %v, want ErrExpired", err)
+	}
+}
+
+func TestDeclineAndCancel(t *testing.T) {
+	store := newTestStore(t)
+	declined := create(t, store, Request{RequesterID: 1, PayerID: 2})
+	cancelled := create(t, store, Request{RequesterID: 1, PayerID: 2})
+
+	_, err := store.Decline(declined.Id, 3, now)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Decline() by stranger error = %v, want ErrNotFound", err)
+	}
+	got, err := store.Decline(declined.Id, 2, now)
+	if err != nil || got.Status != StatusDeclined {
+		t.Errorf("Decline() = %+v, %v", got, err)
+	}
+	_, err = store.Cancel(cancelled.Id, 2, now)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Cancel() by payer error = %v, want ErrNotFound", err)
+	}
+	got, err = store.Cancel(cancelled.Id, 1, now)
+	if err != nil || got.Status != StatusCancelled {
+		t.Errorf("Cancel() = %+v, %v", got, err)
+	}
+}
+
+func TestFailedSaveKeepsMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewStore(storage.NewFile(filepath.Join(dir, "data", "requests.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := create(t, store, Request{RequesterID: 1, PayerID: 2})
+
+	// file in place of directory makes every save fail
+	err = os.RemoveAll(filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "data"), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Create(Request{RequesterID: 1, PayerID: 2, Amount: money.New(100, money.TJS)}, now)
+	if err == nil {
+		t.Fatal("Create() must fail")
+	}
+	_, err = store.Begin(request.Id, 2, now)
+	if err == nil {
+		t.Fatal("Begin() must fail")
+	}
+	if outgoing := store.Outgoing(1); len(outgoing) != 1 || outgoing[0].Status != StatusPending {
+		t.Errorf("store changed after failed save: %+v", outgoing)
+	}
+}
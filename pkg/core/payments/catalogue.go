@@ -0,0 +1,139 @@
+package payments
+
+import (
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"regexp"
+	"strings"
+)
+
+var ErrPayeeNotFound = errors.New("payee not found")
+
+type Category string
+
+const (
+	CategoryMobile    Category = "mobile"
+	CategoryUtilities Category = "utilities"
+)
+
+// Field is one input of payee form, value must match Pattern after spaces are removed
+type Field struct {
+	Name        string
+	Label       string
+	Placeholder string
+	Pattern     *regexp.Regexp
+}
+
+type Payee struct {
+	Id       string
+	Name     string
+	Category Category
+	Currency money.Currency
+	Min      int64 // minor units
+	Max      int64
+	Fields   []Field
+}
+
+var (
+	phoneField = func(prefixes string) Field {
+		return Field{
+			Name:        "phone",
+			Label:       "Номер телефона",
+			Placeholder: "+992 XX XXX XXXX",
+			Pattern:     regexp.MustCompile(`^(\+?992)?(` + prefixes + `)\d{7}$`),
+		}
+	}
+	accountField = func(digits string) Field {
+		return Field{
+			Name:        "account",
+			Label:       "Лицевой счёт",
+			Placeholder: strings.Repeat("0", len(digits)),
+			Pattern:     regexp.MustCompile(`^\d{` + digits + `}$`),
+		}
+	}
+)
+
+var catalogue = []Payee{
+	{Id: "tcell", Name: "Tcell", Category: CategoryMobile, Currency: money.TJS, Min: 100, Max: 500000,
+		Fields: []Field{phoneField("93|92|77")}},
+	{Id: "megafon", Name: "MegaFon Таджикистан", Category: CategoryMobile, Currency: money.TJS, Min: 100, Max: 500000,
+		Fields: []Field{phoneField("90|88|55")}},
+	{Id: "babilon", Name: "Babilon-M", Category: CategoryMobile, Currency: money.TJS, Min: 100, Max: 500000,
+		Fields: []Field{phoneField("98|99")}},
+	{Id: "zet", Name: "ZET-Mobile", Category: CategoryMobile, Currency: money.TJS, Min: 100, Max: 500000,
+		Fields: []Field{phoneField("50|40|00")}},
+	{Id: "barqitojik", Name: "Барки Точик (электричество)", Category: CategoryUtilities, Currency: money.TJS, Min: 100, Max: 10000000,
+		Fields: []Field{accountField("10")}},
+	{Id: "vodokanal", Name: "Душанбе Водоканал", Category: CategoryUtilities, Currency: money.TJS, Min: 100, Max: 10000000,
+		Fields: []Field{accountField("8")}},
+	{Id: "dushanbegaz", Name: "Душанбегаз", Category: CategoryUtilities, Currency: money.TJS, Min: 100, Max: 10000000,
+		Fields: []Field{accountField("9")}},
+}
+
+func Catalogue() []Payee {
+	return catalogue
+}
+
+func PayeeByID(id string) (Payee, error) {
+	for _, payee := range catalogue {
+		if payee.Id == id {
+			return payee, nil
+		}
+	}
+	return Payee{}, ErrPayeeNotFound
+}
+
+// FieldErrors maps field name to error code, "amount" is used for amount
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name, code := range e {
+		names = append(names, name+": "+code)
+	}
+	return "invalid payment: " + strings.Join(names, ", ")
+}
+
+// Validate normalizes values and checks them against payee schema
+func (p Payee) Validate(values map[string]string, amount money.Money) (map[string]string, error) {
+	errs := FieldErrors{}
+	clean := make(map[string]string, len(p.Fields))
+	for _, field := range p.Fields {
+		value := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '-' || r == '(' || r == ')' {
+				return -1
+			}
+			return r
+		}, values[field.Name])
+		switch {
+		case value == "":
+			errs[field.Name] = "err.required"
+		case !field.Pattern.MatchString(value):
+			errs[field.Name] = "err.format"
+		default:
+			clean[field.Name] = value
+		}
+	}
+
+	switch {
+	case amount.Currency != p.Currency:
+		errs["amount"] = "err.currency"
+	case amount.Amount < p.Min:
+		errs["amount"] = "err.min"
+	case amount.Amount > p.Max:
+		errs["amount"] = "err.max"
+	}
+
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	return clean, nil
+}
+
+func (p Payee) MinAmount() money.Money {
+	return money.New(p.Min, p.Currency)
+}
+
+func (p Payee) MaxAmount() money.Money {
+	return money.New(p.Max, p.Currency)
+}
@@ -0,0 +1,134 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Url string
+
+var ErrUnknown = errors.New("unknown error")
+var ErrResponse = errors.New("response error")
+
+type ErrorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+func (e *ErrorResponse) Error() string {
+	return strings.Join(e.Errors, ", ")
+}
+
+// for errors.Is
+func (e *ErrorResponse) Unwrap() error {
+	return ErrResponse
+}
+
+type ModelPayment struct {
+	IdCard   int               `json:"id_card"`
+	PayeeID  string            `json:"payee_id"`
+	Fields   map[string]string `json:"fields"`
+	Count    money.Money       `json:"count"`
+	Currency string            `json:"currency"`
+}
+
+type modelPaymentResponse struct {
+	Id         string      `json:"id"`
+	BalanceNew money.Money `json:"balance_new"`
+}
+
+// Receipt is shown to user after payment
+type Receipt struct {
+	Id         string            `json:"id"`
+	OwnerID    int               `json:"owner_id"`
+	PayeeID    string            `json:"payee_id"`
+	PayeeName  string            `json:"payee_name"`
+	Fields     map[string]string `json:"fields"`
+	CardNumber cards.Number      `json:"card_number"`
+	Amount     money.Money       `json:"amount"`
+	Currency   string            `json:"currency"`
+	Time       time.Time         `json:"time"`
+	// Key is key of payment form, it's paid only once
+	Key string `json:"key,omitempty"`
+	// PendingReference is set when cards service didn't send operation id
+	PendingReference bool `json:"pending_reference,omitempty"`
+}
+
+type Payments struct {
+	url    Url
+	client *http.Client
+}
+
+// NewPayments calls service with client, its transport forwards request id
+func NewPayments(url Url, client *http.Client) *Payments {
+	return &Payments{url: url, client: client}
+}
+
+// Pay debits card in favour of payee, returns id of operation. Id is empty if cards service
+// didn't send it, payment is made then. ErrResponse means payment surely wasn't made
+func (p *Payments) Pay(ctx context.Context, idCard int, payee Payee, fields map[string]string, amount money.Money, token string) (id string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 55*time.Second)
+	defer cancel()
+
+	requestData := ModelPayment{
+		IdCard:   idCard,
+		PayeeID:  payee.Id,
+		Fields:   fields,
+		Count:    amount,
+		Currency: string(amount.Currency),
+	}
+	requestBody, err := json.Marshal(requestData)
+	if err != nil {
+		return "", fmt.Errorf("can't encode requestBody %v: %w", requestData, err)
+	}
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("%s/api/cards/payments", p.url),
+		bytes.NewBuffer(requestBody),
+	)
+	if err != nil {
+		return "", fmt.Errorf("can't create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	response, err := p.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("can't send request: %w", err)
+	}
+	defer response.Body.Close()
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("can't read response: %w", err)
+	}
+
+	switch response.StatusCode {
+	case 200:
+		var responseData modelPaymentResponse
+		// old cards service answers with empty body
+		if len(bytes.TrimSpace(responseBody)) != 0 {
+			err = json.Unmarshal(responseBody, &responseData)
+			if err != nil {
+				return "", fmt.Errorf("can't decode response: %w", err)
+			}
+		}
+		return responseData.Id, nil
+	case 400:
+		var responseData *ErrorResponse
+		err = json.Unmarshal(responseBody, &responseData)
+		if err != nil || responseData == nil {
+			return "", ErrResponse
+		}
+		return "", responseData
+	default:
+		return "", fmt.Errorf("%w: payment answered %d", ErrUnknown, response.StatusCode)
+	}
+}
@@ -0,0 +1,63 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPay(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		id     string
+		failed bool
+		// rejected means payment surely wasn't made and key can be freed
+		rejected bool
+		message  string
+	}{
+		{"paid", 200, `{"id":"op-17","balance_new":95}`, "op-17", false, false, ""},
+		{"paid by old service", 200, ``, "", false, false, ""},
+		{"malformed body", 200, `{"id":`, "", true, false, ""},
+		{"rejected with errors", 400, `{"errors":["insufficient funds"]}`, "", true, true, "insufficient funds"},
+		{"rejected", 400, `oops`, "", true, true, ""},
+		{"server error", 500, ``, "", true, false, ""},
+		{"bad gateway", 502, `{"id":"op-18"}`, "", true, false, ""},
+	}
+	for _, test := range tests {
+		var sent ModelPayment
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path != "/api/cards/payments" || request.Header.Get("Authorization") != "Bearer token" {
+				t.Errorf("%s: request %s %s", test.name, request.URL.Path, request.Header.Get("Authorization"))
+			}
+			body, _ := ioutil.ReadAll(request.Body)
+			_ = json.Unmarshal(body, &sent)
+			writer.WriteHeader(test.status)
+			_, _ = writer.Write([]byte(test.body))
+		}))
+
+		payee := Payee{Id: "tcell", Currency: money.TJS}
+		id, err := NewPayments(Url(server.URL), server.Client()).Pay(context.Background(), 3, payee, map[string]string{"phone": "931234567"}, money.New(1050, money.TJS), "token")
+		server.Close()
+
+		if (err != nil) != test.failed || errors.Is(err, ErrResponse) != test.rejected {
+			t.Errorf("%s: error = %v, want failed %v, rejected %v", test.name, err, test.failed, test.rejected)
+		}
+		var typedErr *ErrorResponse
+		if test.message != "" && (!errors.As(err, &typedErr) || typedErr.Error() != test.message) {
+			t.Errorf("%s: error = %v, want %s", test.name, err, test.message)
+		}
+		if id != test.id {
+			t.Errorf("%s: id = %q, want %q", test.name, id, test.id)
+		}
+		if sent.IdCard != 3 || sent.PayeeID != "tcell" || sent.Count != money.New(1050, money.TJS) || sent.Currency != "TJS" || sent.Fields["phone"] != "931234567" {
+			t.Errorf("%s: sent %+v", test.name, sent)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package payments
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// older receipts are dropped on start, history of operations stays in cards service
+	receiptsRetention = 365 * 24 * time.Hour
+	// payment with ambiguous result blocks its key so long
+	keyTTL = time.Hour
+)
+
+var ErrReceiptNotFound = errors.New("receipt not found")
+var ErrBadKey = errors.New("bad payment key")
+var ErrPaid = errors.New("payment with this key is already made")
+var ErrInProgress = errors.New("payment with this key is in progress")
+
+// Receipts keeps receipts of payments made through front and guards against double submission:
+// payment form carries key, the same key can't be paid twice
+type Receipts struct {
+	mutex    sync.RWMutex
+	lines    *storage.Lines
+	items    []Receipt
+	inFlight map[string]time.Time
+	now      func() time.Time
+}
+
+// NewReceipts loads receipts from lines, receipts older than retention are dropped
+func NewReceipts(lines *storage.Lines) (*Receipts, error) {
+	receipts := &Receipts{lines: lines, inFlight: make(map[string]time.Time), now: time.Now}
+	oldest := receipts.now().Add(-receiptsRetention)
+	err := lines.Load(func(line []byte) (bool, error) {
+		var receipt Receipt
+		err := json.Unmarshal(line, &receipt)
+		if err != nil {
+			return false, err
+		}
+		if receipt.Time.Before(oldest) {
+			return false, nil
+		}
+		receipts.items = append(receipts.items, receipt)
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't load receipts: %w", err)
+	}
+
+	for i := range receipts.items {
+		receipts.items[i].Amount = receipts.items[i].Amount.WithCurrency(money.Currency(receipts.items[i].Currency))
+	}
+	return receipts, nil
+}
+
+// NewKey returns key for new payment form
+func NewKey() (string, error) {
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", fmt.Errorf("can't generate payment key: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// Begin marks key of user as being paid. Receipt is returned with ErrPaid when key is already paid,
+// ErrInProgress means other request with the key isn't finished or its result is unknown
+func (r *Receipts) Begin(ownerID int, key string) (Receipt, error) {
+	if len(key) != 32 {
+		return Receipt{}, ErrBadKey
+	}
+	_, err := hex.DecodeString(key)
+	if err != nil {
+		return Receipt{}, ErrBadKey
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, receipt := range r.items {
+		if receipt.Key == key && receipt.OwnerID == ownerID {
+			return receipt, ErrPaid
+		}
+	}
+	now := r.now()
+	for flight, started := range r.inFlight {
+		if now.Sub(started) >= keyTTL {
+			delete(r.inFlight, flight)
+		}
+	}
+	flight := inFlightKey(ownerID, key)
+	if _, exists := r.inFlight[flight]; exists {
+		return Receipt{}, ErrInProgress
+	}
+	r.inFlight[flight] = now
+	return Receipt{}, nil
+}
+
+// Abort frees key when payment surely wasn't made, so form can be sent again
+func (r *Receipts) Abort(ownerID int, key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.inFlight, inFlightKey(ownerID, key))
+}
+
+// Add saves receipt of made payment. Receipt without operation id gets its key as id
+// and is pending reference until cards service sends operation id.
+// Receipt which can't be saved isn't kept, its key stays in progress, so payment isn't repeated
+func (r *Receipts) Add(receipt Receipt) (Receipt, error) {
+	receipt.Currency = string(receipt.Amount.Currency)
+	if receipt.Id == "" {
+		receipt.Id = receipt.Key
+		receipt.PendingReference = true
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.lines.Append(receipt)
+	if err != nil {
+		return receipt, fmt.Errorf("can't save receipt: %w", err)
+	}
+	r.items = append(r.items, receipt)
+	delete(r.inFlight, inFlightKey(receipt.OwnerID, receipt.Key))
+	return receipt, nil
+}
+
+func (r *Receipts) Get(ownerID int, id string) (Receipt, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, receipt := range r.items {
+		if receipt.Id == id && receipt.OwnerID == ownerID {
+			return receipt, nil
+		}
+	}
+	return Receipt{}, ErrReceiptNotFound
+}
+
+func inFlightKey(ownerID int, key string) string {
+	return strconv.Itoa(ownerID) + ":" + key
+}
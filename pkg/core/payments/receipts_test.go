@@ -0,0 +1,157 @@
+package payments
+
+import (
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func newTestReceipts(t *testing.T, lines *storage.Lines) *Receipts {
+	receipts, err := NewReceipts(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return receipts
+}
+
+func TestBeginBadKey(t *testing.T) {
+	receipts := newTestReceipts(t, storage.NewLines(""))
+	for _, key := range []string{"", "short", strings.Repeat("z", 32), testKey + "00"} {
+		_, err := receipts.Begin(1, key)
+		if !errors.Is(err, ErrBadKey) {
+			t.Errorf("Begin(%q) error = %v, want ErrBadKey", key, err)
+		}
+	}
+}
+
+func TestDoublePayment(t *testing.T) {
+	receipts := newTestReceipts(t, storage.NewLines(""))
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	receipts.now = func() time.Time { return now }
+
+	_, err := receipts.Begin(1, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = receipts.Begin(1, testKey)
+	if !errors.Is(err, ErrInProgress) {
+		t.Errorf("second Begin() error = %v, want ErrInProgress", err)
+	}
+	// key belongs to user, other user isn't blocked
+	_, err = receipts.Begin(2, testKey)
+	if err != nil {
+		t.Errorf("Begin() of other user error = %v", err)
+	}
+
+	added, err := receipts.Add(Receipt{OwnerID: 1, PayeeID: "tcell", Amount: money.New(1050, money.TJS), Time: now, Key: testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.Id != testKey || !added.PendingReference || added.Currency != "TJS" {
+		t.Errorf("receipt without operation id = %+v", added)
+	}
+	receipt, err := receipts.Begin(1, testKey)
+	if !errors.Is(err, ErrPaid) || receipt.Id != added.Id {
+		t.Errorf("Begin() after payment = %+v, %v, want ErrPaid", receipt, err)
+	}
+	_, err = receipts.Get(2, added.Id)
+	if !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("Get() by other user error = %v, want ErrReceiptNotFound", err)
+	}
+}
+
+func TestAbortAndExpiredKey(t *testing.T) {
+	receipts := newTestReceipts(t, storage.NewLines(""))
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	receipts.now = func() time.Time { return now }
+
+	_, err := receipts.Begin(1, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipts.Abort(1, testKey)
+	_, err = receipts.Begin(1, testKey)
+	if err != nil {
+		t.Errorf("Begin() after Abort() error = %v", err)
+	}
+
+	// key of payment with unknown result is freed after keyTTL
+	now = now.Add(keyTTL - time.Second)
+	_, err = receipts.Begin(1, testKey)
+	if !errors.Is(err, ErrInProgress) {
+		t.Errorf("Begin() before keyTTL error = %v, want ErrInProgress", err)
+	}
+	now = now.Add(time.Second)
+	_, err = receipts.Begin(1, testKey)
+	if err != nil {
+		t.Errorf("Begin() after keyTTL error = %v", err)
+	}
+}
+
+func TestReceiptsReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "receipts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lines := storage.NewLines(filepath.Join(dir, "receipts.jsonl"))
+	receipts := newTestReceipts(t, lines)
+
+	old := Receipt{Id: "op-1", OwnerID: 1, Amount: money.New(1500, money.JPY), Time: time.Now().Add(-receiptsRetention - time.Hour)}
+	fresh := Receipt{Id: "op-2", OwnerID: 1, Amount: money.New(1500, money.JPY), Time: time.Now()}
+	for _, receipt := range []Receipt{old, fresh} {
+		_, err = receipts.Add(receipt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded := newTestReceipts(t, lines)
+	_, err = reloaded.Get(1, "op-1")
+	if !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("receipt older than retention is kept: %v", err)
+	}
+	got, err := reloaded.Get(1, "op-2")
+	if err != nil || got.Amount != money.New(1500, money.JPY) {
+		t.Errorf("Get() = %+v, %v, want 1500 JPY", got, err)
+	}
+}
+
+func TestFailedAddKeepsKeyBusy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "receipts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	receipts := newTestReceipts(t, storage.NewLines(filepath.Join(dir, "data", "receipts.jsonl")))
+	// file in place of directory makes every append fail
+	err = ioutil.WriteFile(filepath.Join(dir, "data"), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = receipts.Begin(1, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := receipts.Add(Receipt{Id: "op-1", OwnerID: 1, Amount: money.New(100, money.TJS), Time: time.Now(), Key: testKey})
+	if err == nil {
+		t.Fatal("Add() must fail")
+	}
+	_, err = receipts.Get(1, added.Id)
+	if !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("unsaved receipt is kept: %v", err)
+	}
+	_, err = receipts.Begin(1, testKey)
+	if !errors.Is(err, ErrInProgress) {
+		t.Errorf("Begin() after failed Add() error = %v, want ErrInProgress", err)
+	}
+}
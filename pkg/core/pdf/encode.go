@@ -0,0 +1,57 @@
+package pdf
+
+import "strings"
+
+// winAnsi covers runes of cp1252 outside of ASCII and Latin-1 that we may meet in texts
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+	'\u202f': 0xa0,
+}
+
+// standard fonts have no cyrillic glyphs, so names are transliterated
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'ғ': "gh", 'ӣ': "i", 'қ': "q", 'ӯ': "u", 'ҳ': "h", 'ҷ': "j",
+	'№': "No.",
+}
+
+// escape encodes text to WinAnsiEncoding and escapes it for PDF string literal
+func escape(text string) string {
+	var builder strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			builder.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f:
+			builder.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			builder.WriteByte(byte(r))
+		case winAnsi[r] != 0:
+			builder.WriteByte(winAnsi[r])
+		default:
+			builder.WriteString(transliterate(r))
+		}
+	}
+	return builder.String()
+}
+
+func transliterate(r rune) string {
+	if latin, ok := cyrillic[r]; ok {
+		return latin
+	}
+	lower := []rune(strings.ToLower(string(r)))[0]
+	if latin, ok := cyrillic[lower]; ok {
+		if latin == "" {
+			return ""
+		}
+		return strings.ToUpper(latin[:1]) + latin[1:]
+	}
+	return "?"
+}
@@ -0,0 +1,121 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 in points
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+)
+
+// Font is resource name of one of standard fonts, they are not embedded
+type Font string
+
+const (
+	Regular Font = "F1"
+	Bold    Font = "F2"
+)
+
+var fonts = []struct {
+	name     Font
+	baseFont string
+}{
+	{Regular, "Helvetica"},
+	{Bold, "Helvetica-Bold"},
+}
+
+// Document is minimal PDF writer: text and lines on A4 pages
+type Document struct {
+	pages []*Page
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+type Page struct {
+	content bytes.Buffer
+}
+
+// Text draws text with baseline at (x, y), origin is bottom left corner
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(text))
+}
+
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	buffered := bufio.NewWriter(w)
+	out := &counter{w: buffered}
+	var offsets []int64
+	object := func(body string) int {
+		offsets = append(offsets, out.n)
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+		return len(offsets)
+	}
+
+	fmt.Fprint(out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// catalog and page tree are 1 and 2, pages refer to tree before it is written
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	pagesID := len(fonts) + 3
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pagesID+i*2)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	resources := make([]string, len(fonts))
+	for i, font := range fonts {
+		id := object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.baseFont))
+		resources[i] = fmt.Sprintf("/%s %d 0 R", font.name, id)
+	}
+	for _, page := range d.pages {
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, strings.Join(resources, " "), len(offsets)+2,
+		))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", page.content.Len(), page.content.Bytes()))
+	}
+
+	xref := out.n
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	if out.err != nil {
+		return out.n, out.err
+	}
+	return out.n, buffered.Flush()
+}
+
+type counter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *counter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,84 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Receipt No. 1", "Receipt No. 1"},
+		{`a (b) \c`, `a \(b\) \\c`},
+		{"line\nbreak", "line break"},
+		{"1 500,00 €", "1 500,00 \x80"},
+		{"100 000 JPY", "100\xa0000 JPY"},
+		{"Перевод № 5", "Perevod No. 5"},
+		{"Ҷаъфар Щукин", "Jafar Shchukin"},
+		{"日本", "??"},
+	}
+	for _, test := range tests {
+		got := escape(test.text)
+		if got != test.want {
+			t.Errorf("escape(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	document := New()
+	first := document.AddPage()
+	first.Text(60, 700, Bold, 20, "My Bank (test)")
+	first.Line(60, 690, 535, 690)
+	second := document.AddPage()
+	second.Text(60, 700, Regular, 11, "Сумма: 1 500 JPY")
+
+	buffer := &bytes.Buffer{}
+	n, err := document.WriteTo(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buffer.Bytes()
+	if n != int64(len(data)) {
+		t.Errorf("WriteTo() = %d, wrote %d bytes", n, len(data))
+	}
+
+	for _, text := range []string{
+		"%PDF-1.4\n",
+		"/Type /Pages /Kids [5 0 R 7 0 R] /Count 2",
+		"/BaseFont /Helvetica-Bold",
+		"BT /F2 20.00 Tf 60.00 700.00 Td (My Bank \\(test\\)) Tj ET",
+		"60.00 690.00 m 535.00 690.00 l S",
+		"BT /F1 11.00 Tf 60.00 700.00 Td (Summa: 1 500 JPY) Tj ET",
+		"%%EOF\n",
+	} {
+		if !bytes.Contains(data, []byte(text)) {
+			t.Errorf("document has no %q", text)
+		}
+	}
+
+	// every xref entry must point to its object
+	xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if xref == nil {
+		t.Fatal("no startxref")
+	}
+	start, _ := strconv.Atoi(string(xref[1]))
+	if !bytes.HasPrefix(data[start:], []byte("xref\n0 9\n")) {
+		t.Fatalf("startxref %d doesn't point to xref table", start)
+	}
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[start:], -1)
+	if len(offsets) != 8 {
+		t.Fatalf("xref has %d objects, want 8", len(offsets))
+	}
+	for i, offset := range offsets {
+		position, _ := strconv.Atoi(string(offset[1]))
+		if !bytes.HasPrefix(data[position:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))) {
+			t.Errorf("object %d isn't at offset %d", i+1, position)
+		}
+	}
+}
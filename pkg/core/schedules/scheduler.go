@@ -0,0 +1,110 @@
+package schedules
+
+import (
+	"context"
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"time"
+)
+
+// Clock is injected so scheduler can be driven by fake time
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Executor performs transfer of schedule, errors before transfer are retried,
+// ErrUnknownResult isn't
+type Executor interface {
+	ExecuteSchedule(ctx context.Context, schedule Schedule) error
+}
+
+type Scheduler struct {
+	store       *Store
+	executor    Executor
+	clock       Clock
+	interval    time.Duration
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+func NewScheduler(store *Store, executor Executor, clock Clock, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:       store,
+		executor:    executor,
+		clock:       clock,
+		interval:    interval,
+		maxAttempts: 3,
+		retryDelay:  5 * time.Minute,
+	}
+}
+
+// Run executes due schedules every interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.RunDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue executes schedules due at clock.Now() once
+func (s *Scheduler) RunDue(ctx context.Context) {
+	now := s.clock.Now()
+	for _, schedule := range s.store.Due(now) {
+		err := s.executor.ExecuteSchedule(ctx, schedule)
+		finished := s.clock.Now()
+
+		run := Run{At: finished, OK: err == nil}
+		attempts := 0
+		status := StatusActive
+		var nextRun time.Time
+
+		if errors.Is(err, ErrUnknownResult) {
+			// money could be sent, so run isn't repeated until user checks history
+			logging.Errorf(ctx, "scheduled transfer %d needs review: %v", schedule.Id, err)
+			run.Error = err.Error()
+			s.record(ctx, schedule.Id, run, time.Time{}, 0, StatusReview)
+			continue
+		}
+		if err != nil {
+			logging.Errorf(ctx, "scheduled transfer %d failed (attempt %d): %v", schedule.Id, schedule.Attempts+1, err)
+			run.Error = err.Error()
+			attempts = schedule.Attempts + 1
+			if attempts < s.maxAttempts {
+				// retry later with growing delay, occurrence stays the same
+				nextRun = finished.Add(s.retryDelay * time.Duration(1<<uint(attempts-1)))
+				s.record(ctx, schedule.Id, run, nextRun, attempts, status)
+				continue
+			}
+			attempts = 0
+		}
+
+		next, ok := schedule.Next(now)
+		if ok {
+			nextRun = next
+		} else if err != nil {
+			status = StatusFailed
+		} else {
+			status = StatusDone
+		}
+		s.record(ctx, schedule.Id, run, nextRun, attempts, status)
+	}
+}
+
+func (s *Scheduler) record(ctx context.Context, id int64, run Run, nextRun time.Time, attempts int, status Status) {
+	err := s.store.Record(id, run, nextRun, attempts, status)
+	if err != nil {
+		logging.Errorf(ctx, "can't record schedule %d run: %v", id, err)
+	}
+}
@@ -0,0 +1,183 @@
+package schedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// fakeExecutor fails while errs has errors and counts executions
+type fakeExecutor struct {
+	calls int
+	errs  []error
+}
+
+func (e *fakeExecutor) ExecuteSchedule(ctx context.Context, schedule Schedule) error {
+	e.calls++
+	if len(e.errs) == 0 {
+		return nil
+	}
+	err := e.errs[0]
+	e.errs = e.errs[1:]
+	return err
+}
+
+func newTestScheduler(t *testing.T, schedule Schedule, now time.Time) (*Scheduler, *Store, *fakeClock, *fakeExecutor) {
+	store, err := NewStore(storage.NewFile(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule.Amount = money.New(1000, money.TJS)
+	_, err = store.Create(schedule, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: now}
+	executor := &fakeExecutor{}
+	return NewScheduler(store, executor, clock, time.Minute), store, clock, executor
+}
+
+func TestRunDueMonthly(t *testing.T) {
+	start := date(2026, 1, 10, 9)
+	scheduler, store, clock, executor := newTestScheduler(t, Schedule{OwnerID: 1, Recurrence: Monthly, Day: 10, Start: start}, date(2026, 1, 1, 0))
+
+	scheduler.RunDue(context.Background())
+	if executor.calls != 0 {
+		t.Fatalf("executed before start: %d calls", executor.calls)
+	}
+
+	// three years of monthly runs, schedule must stay active
+	clock.now = start
+	for i := 0; i < 36; i++ {
+		scheduler.RunDue(context.Background())
+		schedule := store.List(1)[0]
+		if schedule.Status != StatusActive {
+			t.Fatalf("run %d: status %s", i, schedule.Status)
+		}
+		want := start.AddDate(0, i+1, 0)
+		if !schedule.NextRun.Equal(want) {
+			t.Fatalf("run %d: next run %s, want %s", i, schedule.NextRun, want)
+		}
+		clock.now = schedule.NextRun
+	}
+	if executor.calls != 36 {
+		t.Errorf("calls = %d, want 36", executor.calls)
+	}
+}
+
+func TestRunDueOnce(t *testing.T) {
+	start := date(2026, 1, 10, 9)
+	scheduler, store, clock, executor := newTestScheduler(t, Schedule{OwnerID: 1, Recurrence: Once, Start: start}, date(2026, 1, 1, 0))
+
+	clock.now = start.Add(time.Minute)
+	scheduler.RunDue(context.Background())
+	scheduler.RunDue(context.Background())
+	schedule := store.List(1)[0]
+	if executor.calls != 1 || schedule.Status != StatusDone {
+		t.Errorf("calls = %d, status = %s, want 1 call and done", executor.calls, schedule.Status)
+	}
+	if len(schedule.Runs) != 1 || !schedule.Runs[0].OK {
+		t.Errorf("runs = %v", schedule.Runs)
+	}
+}
+
+func TestRunDueRetry(t *testing.T) {
+	start := date(2026, 1, 10, 9)
+	scheduler, store, clock, executor := newTestScheduler(t, Schedule{OwnerID: 1, Recurrence: Weekly, Start: start}, date(2026, 1, 1, 0))
+	failure := errors.New("cards service is down")
+	executor.errs = []error{failure, failure}
+
+	clock.now = start
+	scheduler.RunDue(context.Background())
+	schedule := store.List(1)[0]
+	if schedule.Attempts != 1 || !schedule.NextRun.Equal(start.Add(scheduler.retryDelay)) {
+		t.Fatalf("after first failure: attempts %d, next run %s", schedule.Attempts, schedule.NextRun)
+	}
+
+	// retry isn't due yet
+	clock.now = start.Add(time.Minute)
+	scheduler.RunDue(context.Background())
+	if executor.calls != 1 {
+		t.Fatalf("retried too early: %d calls", executor.calls)
+	}
+
+	clock.now = schedule.NextRun
+	scheduler.RunDue(context.Background())
+	schedule = store.List(1)[0]
+	if schedule.Attempts != 2 || !schedule.NextRun.Equal(clock.now.Add(2*scheduler.retryDelay)) {
+		t.Fatalf("after second failure: attempts %d, next run %s", schedule.Attempts, schedule.NextRun)
+	}
+
+	clock.now = schedule.NextRun
+	scheduler.RunDue(context.Background())
+	schedule = store.List(1)[0]
+	if schedule.Attempts != 0 || schedule.Status != StatusActive || !schedule.NextRun.Equal(start.AddDate(0, 0, 7)) {
+		t.Errorf("after success: attempts %d, status %s, next run %s", schedule.Attempts, schedule.Status, schedule.NextRun)
+	}
+	if len(schedule.Runs) != 3 || schedule.Runs[0].Error != failure.Error() || !schedule.Runs[2].OK {
+		t.Errorf("runs = %v", schedule.Runs)
+	}
+}
+
+func TestRunDueFailsAfterMaxAttempts(t *testing.T) {
+	start := date(2026, 1, 10, 9)
+	scheduler, store, clock, executor := newTestScheduler(t, Schedule{OwnerID: 1, Recurrence: Once, Start: start}, date(2026, 1, 1, 0))
+	failure := errors.New("insufficient funds")
+	executor.errs = []error{failure, failure, failure}
+
+	clock.now = start
+	for i := 0; i < scheduler.maxAttempts; i++ {
+		scheduler.RunDue(context.Background())
+		clock.now = store.List(1)[0].NextRun
+	}
+	schedule := store.List(1)[0]
+	if executor.calls != scheduler.maxAttempts || schedule.Status != StatusFailed {
+		t.Errorf("calls = %d, status = %s, want %d calls and failed", executor.calls, schedule.Status, scheduler.maxAttempts)
+	}
+}
+
+func TestRunDueKeepsCancelled(t *testing.T) {
+	start := date(2026, 1, 10, 9)
+	scheduler, store, clock, executor := newTestScheduler(t, Schedule{OwnerID: 1, Recurrence: Weekly, Start: start}, date(2026, 1, 1, 0))
+	err := store.Cancel(1, store.List(1)[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.now = start
+	scheduler.RunDue(context.Background())
+	if executor.calls != 0 || store.List(1)[0].Status != StatusCancelled {
+		t.Errorf("cancelled schedule executed: %d calls", executor.calls)
+	}
+}
+
+func TestRunDueUnknownResultRunsOnce(t *testing.T) {
+	start := date(2026, 1, 10, 9)
+	scheduler, store, clock, executor := newTestScheduler(t, Schedule{OwnerID: 1, Recurrence: Weekly, Start: start}, date(2026, 1, 1, 0))
+	executor.errs = []error{fmt.Errorf("%w: timeout", ErrUnknownResult)}
+
+	clock.now = start
+	for i := 0; i < 5; i++ {
+		scheduler.RunDue(context.Background())
+		clock.now = clock.now.Add(24 * time.Hour)
+	}
+	schedule := store.List(1)[0]
+	if executor.calls != 1 || schedule.Status != StatusReview {
+		t.Errorf("calls = %d, status = %s, want 1 call and review", executor.calls, schedule.Status)
+	}
+	if len(schedule.Runs) != 1 || schedule.Runs[0].OK {
+		t.Errorf("runs = %v", schedule.Runs)
+	}
+}
@@ -0,0 +1,272 @@
+package schedules
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/cards"
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("schedule not found")
+var ErrBadRecurrence = errors.New("bad recurrence")
+var ErrInPast = errors.New("schedule start is in the past")
+
+// ErrUnknownResult is returned by executor when transfer could be done,
+// such run is never repeated
+var ErrUnknownResult = errors.New("transfer result is unknown")
+
+type Recurrence string
+
+const (
+	Once    Recurrence = "once"
+	Weekly  Recurrence = "weekly"
+	Monthly Recurrence = "monthly"
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusDone      Status = "done"
+	StatusFailed    Status = "failed"
+	StatusReview    Status = "review" // transfer result is unknown, schedule is stopped
+	StatusCancelled Status = "cancelled"
+)
+
+// how many runs are kept in schedule history
+const maxRuns = 10
+
+// Run is outcome of one execution attempt
+type Run struct {
+	At    time.Time `json:"at"`
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+}
+
+type Schedule struct {
+	Id         int64        `json:"id"`
+	OwnerID    int          `json:"owner_id"`
+	IdCard     int          `json:"id_card"`
+	Number     cards.Number `json:"number"`
+	Amount     money.Money  `json:"amount"`
+	Currency   string       `json:"currency"`
+	Recurrence Recurrence   `json:"recurrence"`
+	// Day is day of month for Monthly, for Weekly weekday of Start is used
+	Day       int       `json:"day"`
+	Start     time.Time `json:"start"`
+	NextRun   time.Time `json:"next_run"`
+	Attempts  int       `json:"attempts"`
+	Status    Status    `json:"status"`
+	Runs      []Run     `json:"runs"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// restore currency of amount, it is not part of money json
+func (s *Schedule) normalize() {
+	s.Amount = s.Amount.WithCurrency(money.Currency(s.Currency))
+}
+
+// Next returns first occurrence strictly after moment
+func (s Schedule) Next(moment time.Time) (time.Time, bool) {
+	switch s.Recurrence {
+	case Weekly:
+		next := s.Start
+		if next.After(moment) {
+			return next, true
+		}
+		weeks := int(moment.Sub(next).Hours()/24/7) + 1
+		next = next.AddDate(0, 0, 7*weeks)
+		for !next.After(moment) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next, true
+	case Monthly:
+		// occurrence is in month of moment (or of start, if it is later) or in the next one
+		from := s.Start
+		if moment.After(from) {
+			from = moment.In(s.Start.Location())
+		}
+		year, month, _ := from.Date()
+		for i := 0; i < 2; i++ {
+			next := dayOfMonth(year, month+time.Month(i), s.Day, s.Start)
+			if next.After(moment) && !next.Before(s.Start) {
+				return next, true
+			}
+		}
+		return time.Time{}, false
+	default:
+		if s.Start.After(moment) {
+			return s.Start, true
+		}
+		return time.Time{}, false
+	}
+}
+
+// dayOfMonth clamps day to the last day of month: 31 -> 30 april
+func dayOfMonth(year int, month time.Month, day int, clock time.Time) time.Time {
+	first := time.Date(year, month, 1, clock.Hour(), clock.Minute(), 0, 0, clock.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+type Store struct {
+	mutex sync.RWMutex
+	file  *storage.File
+	data  storeData
+}
+
+type storeData struct {
+	NextID int64      `json:"next_id"`
+	Items  []Schedule `json:"items"`
+}
+
+func NewStore(file *storage.File) (*Store, error) {
+	store := &Store{file: file}
+	err := file.Load(&store.data)
+	if err != nil {
+		return nil, fmt.Errorf("can't load schedules: %w", err)
+	}
+	for i := range store.data.Items {
+		store.data.Items[i].normalize()
+	}
+	return store, nil
+}
+
+// Create validates schedule and calculates first run
+func (s *Store) Create(schedule Schedule, now time.Time) (Schedule, error) {
+	switch schedule.Recurrence {
+	case Once, Weekly:
+	case Monthly:
+		if schedule.Day < 1 || schedule.Day > 31 {
+			return Schedule{}, fmt.Errorf("%w: day %d", ErrBadRecurrence, schedule.Day)
+		}
+	default:
+		return Schedule{}, fmt.Errorf("%w: %s", ErrBadRecurrence, schedule.Recurrence)
+	}
+	if !schedule.Amount.IsPositive() {
+		return Schedule{}, money.ErrInvalidAmount
+	}
+	if schedule.Start.Before(now.Add(-24 * time.Hour)) {
+		return Schedule{}, ErrInPast
+	}
+
+	next, ok := schedule.Next(now)
+	if !ok {
+		// today's date is allowed for one-off, execute on next scheduler tick
+		next = now
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data := s.copyData()
+	data.NextID++
+	schedule.Id = data.NextID
+	schedule.Currency = string(schedule.Amount.Currency)
+	schedule.NextRun = next
+	schedule.Status = StatusActive
+	schedule.CreatedAt = now
+	data.Items = append(data.Items, schedule)
+	err := s.save(data)
+	if err != nil {
+		return Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (s *Store) List(ownerID int) []Schedule {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]Schedule, 0)
+	for _, item := range s.data.Items {
+		if item.OwnerID == ownerID {
+			result = append(result, item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].NextRun.Before(result[j].NextRun)
+	})
+	return result
+}
+
+func (s *Store) Cancel(ownerID int, id int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, item := range s.data.Items {
+		if item.Id == id && item.OwnerID == ownerID {
+			if item.Status != StatusActive {
+				return nil
+			}
+			data := s.copyData()
+			data.Items[i].Status = StatusCancelled
+			return s.save(data)
+		}
+	}
+	return ErrNotFound
+}
+
+// Due returns active schedules which should be executed at moment
+func (s *Store) Due(moment time.Time) []Schedule {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]Schedule, 0)
+	for _, item := range s.data.Items {
+		if item.Status == StatusActive && !item.NextRun.After(moment) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Record saves outcome of execution and moves schedule to the next run
+func (s *Store) Record(id int64, run Run, nextRun time.Time, attempts int, status Status) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, item := range s.data.Items {
+		if item.Id != id {
+			continue
+		}
+		if item.Status == StatusCancelled {
+			// cancelled while executing, keep it cancelled
+			status = StatusCancelled
+		}
+		runs := append(append([]Run(nil), item.Runs...), run)
+		if len(runs) > maxRuns {
+			runs = runs[len(runs)-maxRuns:]
+		}
+		item.Runs = runs
+		item.NextRun = nextRun
+		item.Attempts = attempts
+		item.Status = status
+		data := s.copyData()
+		data.Items[i] = item
+		return s.save(data)
+	}
+	return ErrNotFound
+}
+
+// copyData returns data which can be changed without touching store
+func (s *Store) copyData() storeData {
+	return storeData{NextID: s.data.NextID, Items: append([]Schedule(nil), s.data.Items...)}
+}
+
+// save writes data and only then swaps it in, so memory doesn't differ from file on error
+func (s *Store) save(data storeData) error {
+	err := s.file.Save(data)
+	if err != nil {
+		return fmt.Errorf("can't save schedules: %w", err)
+	}
+	s.data = data
+	return nil
+}
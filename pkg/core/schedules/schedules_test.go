@@ -0,0 +1,131 @@
+package schedules
+
+import (
+	"github.com/jafarsirojov/bank-front/pkg/core/money"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		moment   time.Time
+		want     time.Time
+		ok       bool
+	}{
+		{
+			name:     "once before start",
+			schedule: Schedule{Recurrence: Once, Start: date(2026, 3, 10, 9)},
+			moment:   date(2026, 3, 1, 0),
+			want:     date(2026, 3, 10, 9),
+			ok:       true,
+		},
+		{
+			name:     "once after start",
+			schedule: Schedule{Recurrence: Once, Start: date(2026, 3, 10, 9)},
+			moment:   date(2026, 3, 10, 9),
+			ok:       false,
+		},
+		{
+			name:     "weekly keeps weekday",
+			schedule: Schedule{Recurrence: Weekly, Start: date(2026, 3, 2, 9)},
+			moment:   date(2026, 3, 16, 9),
+			want:     date(2026, 3, 23, 9),
+			ok:       true,
+		},
+		{
+			name:     "monthly in same month",
+			schedule: Schedule{Recurrence: Monthly, Day: 20, Start: date(2026, 3, 2, 9)},
+			moment:   date(2026, 3, 5, 0),
+			want:     date(2026, 3, 20, 9),
+			ok:       true,
+		},
+		{
+			name:     "monthly day before start goes to next month",
+			schedule: Schedule{Recurrence: Monthly, Day: 1, Start: date(2026, 3, 2, 9)},
+			moment:   date(2026, 2, 1, 0),
+			want:     date(2026, 4, 1, 9),
+			ok:       true,
+		},
+		{
+			name:     "monthly clamps to last day",
+			schedule: Schedule{Recurrence: Monthly, Day: 31, Start: date(2026, 1, 31, 9)},
+			moment:   date(2026, 1, 31, 9),
+			want:     date(2026, 2, 28, 9),
+			ok:       true,
+		},
+		{
+			name:     "monthly after many years",
+			schedule: Schedule{Recurrence: Monthly, Day: 15, Start: date(2026, 1, 1, 9)},
+			moment:   date(2031, 12, 15, 9),
+			want:     date(2032, 1, 15, 9),
+			ok:       true,
+		},
+		{
+			name:     "monthly in leap year",
+			schedule: Schedule{Recurrence: Monthly, Day: 30, Start: date(2026, 1, 1, 9)},
+			moment:   date(2028, 2, 1, 0),
+			want:     date(2028, 2, 29, 9),
+			ok:       true,
+		},
+	}
+	for _, test := range tests {
+		got, ok := test.schedule.Next(test.moment)
+		if ok != test.ok || !got.Equal(test.want) {
+			t.Errorf("%s: Next() = %s, %t, want %s, %t", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestFailedSaveKeepsMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schedules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewStore(storage.NewFile(filepath.Join(dir, "data", "schedules.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := date(2026, 1, 1, 0)
+	created, err := store.Create(Schedule{OwnerID: 1, Recurrence: Weekly, Start: date(2026, 1, 10, 9), Amount: money.New(1000, money.TJS)}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// file in place of directory makes every save fail
+	err = os.RemoveAll(filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "data"), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Create(Schedule{OwnerID: 1, Recurrence: Once, Start: date(2026, 1, 10, 9), Amount: money.New(1000, money.TJS)}, now)
+	if err == nil {
+		t.Error("Create() must fail")
+	}
+	err = store.Cancel(1, created.Id)
+	if err == nil {
+		t.Error("Cancel() must fail")
+	}
+	err = store.Record(created.Id, Run{At: now, OK: true}, date(2026, 1, 17, 9), 0, StatusActive)
+	if err == nil {
+		t.Error("Record() must fail")
+	}
+	list := store.List(1)
+	if len(list) != 1 || list[0].Status != StatusActive || len(list[0].Runs) != 0 || !list[0].NextRun.Equal(created.NextRun) {
+		t.Errorf("store changed after failed save: %+v", list)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File keeps json document on disk for data which upstream services don't store.
+// Empty path means memory only (nothing is loaded or saved).
+type File struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Dir returns File for name inside dir, or memory only File if dir is empty
+func Dir(dir string, name string) *File {
+	if dir == "" {
+		return NewFile("")
+	}
+	return NewFile(filepath.Join(dir, name))
+}
+
+// Load decodes file into dto, missing file is not an error
+func (f *File) Load(dto interface{}) error {
+	if f.path == "" {
+		return nil
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't read %s: %w", f.path, err)
+	}
+	err = json.Unmarshal(data, dto)
+	if err != nil {
+		return fmt.Errorf("can't decode %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// Save writes dto to temp file and renames it, so file is never half written
+func (f *File) Save(dto interface{}) error {
+	if f.path == "" {
+		return nil
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := json.MarshalIndent(dto, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't encode %s: %w", f.path, err)
+	}
+	err = os.MkdirAll(filepath.Dir(f.path), 0700)
+	if err != nil {
+		return fmt.Errorf("can't create dir for %s: %w", f.path, err)
+	}
+	tmp := f.path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("can't write %s: %w", tmp, err)
+	}
+	err = os.Rename(tmp, f.path)
+	if err != nil {
+		return fmt.Errorf("can't rename %s: %w", tmp, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Lines keeps json documents one per line for data which only grows, e.g. receipts.
+// Documents are appended, so file isn't rewritten on every change.
+// Empty path means memory only (nothing is loaded or saved).
+type Lines struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func NewLines(path string) *Lines {
+	return &Lines{path: path}
+}
+
+// LinesDir returns Lines for name inside dir, or memory only Lines if dir is empty
+func LinesDir(dir string, name string) *Lines {
+	if dir == "" {
+		return NewLines("")
+	}
+	return NewLines(filepath.Join(dir, name))
+}
+
+// Load passes every line to decode, missing file is not an error. Lines for which decode
+// returns false are dropped and file is rewritten without them
+func (l *Lines) Load(decode func(line []byte) (keep bool, err error)) error {
+	if l.path == "" {
+		return nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't read %s: %w", l.path, err)
+	}
+
+	var kept bytes.Buffer
+	dropped := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		keep, err := decode(line)
+		if err != nil {
+			return fmt.Errorf("can't decode %s line %d: %w", l.path, number, err)
+		}
+		if !keep {
+			dropped = true
+			continue
+		}
+		kept.Write(line)
+		kept.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("can't read %s: %w", l.path, err)
+	}
+	if !dropped {
+		return nil
+	}
+
+	tmp := l.path + ".tmp"
+	err = ioutil.WriteFile(tmp, kept.Bytes(), 0600)
+	if err != nil {
+		return fmt.Errorf("can't write %s: %w", tmp, err)
+	}
+	err = os.Rename(tmp, l.path)
+	if err != nil {
+		return fmt.Errorf("can't rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+// Append writes dto as one line at the end of file
+func (l *Lines) Append(dto interface{}) error {
+	if l.path == "" {
+		return nil
+	}
+	line, err := json.Marshal(dto)
+	if err != nil {
+		return fmt.Errorf("can't encode %s: %w", l.path, err)
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	err = os.MkdirAll(filepath.Dir(l.path), 0700)
+	if err != nil {
+		return fmt.Errorf("can't create dir for %s: %w", l.path, err)
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("can't open %s: %w", l.path, err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("can't write %s: %w", l.path, err)
+	}
+	return file.Close()
+}
@@ -24,6 +24,13 @@ var defaultHeader = Header{
 	Typ: "JWT",
 }
 
+// Derive makes separate key for other purpose, so token signed for one purpose is not valid for others
+func Derive(secret Secret, purpose string) Secret {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
 func Encode(payload interface{}, secret Secret) (token string, err error) {
 	headerJSON, err := json.Marshal(defaultHeader)
 	if err != nil {
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// RequestIDHeader is read from incoming request and sent to upstream services
+const RequestIDHeader = "X-Request-ID"
+
+type contextKey string
+
+var (
+	requestIDKey = contextKey("request_id")
+	fieldsKey    = contextKey("fields")
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// fields are filled by inner middlewares and handlers while outer middleware
+// which made them still has only its own context
+type fields struct {
+	mutex sync.Mutex
+	items []interface{}
+}
+
+// WithFields makes place for fields of request, all records with ctx have them
+func WithFields(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fieldsKey, &fields{})
+}
+
+// AddField adds field to records of request, it does nothing without WithFields
+func AddField(ctx context.Context, key string, value interface{}) {
+	bag, ok := ctx.Value(fieldsKey).(*fields)
+	if !ok {
+		return
+	}
+	bag.mutex.Lock()
+	defer bag.mutex.Unlock()
+	for i := 0; i < len(bag.items); i += 2 {
+		if bag.items[i] == key {
+			bag.items[i+1] = value
+			return
+		}
+	}
+	bag.items = append(bag.items, key, value)
+}
+
+func requestFields(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	bag, ok := ctx.Value(fieldsKey).(*fields)
+	if !ok {
+		return nil
+	}
+	bag.mutex.Lock()
+	defer bag.mutex.Unlock()
+	return append([]interface{}(nil), bag.items...)
+}
+
+// Transport sends request id from context of outgoing request to upstream service
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	id := RequestID(request.Context())
+	if id == "" || request.Header.Get(RequestIDHeader) != "" {
+		return base.RoundTrip(request)
+	}
+	// RoundTripper must not change request
+	forwarded := request.Clone(request.Context())
+	forwarded.Header.Set(RequestIDHeader, id)
+	return base.RoundTrip(forwarded)
+}
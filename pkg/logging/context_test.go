@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received = request.Header.Get(RequestIDHeader)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: Transport{Base: server.Client().Transport}}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		header string
+		want   string
+	}{
+		{"forwarded", WithRequestID(context.Background(), "req-1"), "", "req-1"},
+		{"no id", context.Background(), "", ""},
+		{"id set by caller", WithRequestID(context.Background(), "req-1"), "caller", "caller"},
+	}
+	for _, test := range tests {
+		request, err := http.NewRequestWithContext(test.ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.header != "" {
+			request.Header.Set(RequestIDHeader, test.header)
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if received != test.want {
+			t.Errorf("%s: upstream got %q, want %q", test.name, received, test.want)
+		}
+		if request.Header.Get(RequestIDHeader) != test.header {
+			t.Errorf("%s: request of caller is changed", test.name)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// value is what is written for field, errors and Stringers are written as text
+func value(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return typed.Error()
+	case time.Duration:
+		return typed.String()
+	case fmt.Stringer:
+		return typed.String()
+	default:
+		return v
+	}
+}
+
+func key(k interface{}) string {
+	if text, ok := k.(string); ok {
+		return text
+	}
+	return fmt.Sprint(k)
+}
+
+// encodeJSON keeps order of fields, encoding/json would sort keys of map
+func encodeJSON(record []interface{}) []byte {
+	var line bytes.Buffer
+	line.WriteByte('{')
+	for i := 0; i < len(record); i += 2 {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		name, _ := json.Marshal(key(record[i]))
+		line.Write(name)
+		line.WriteByte(':')
+		var field interface{} = "!MISSING"
+		if i+1 < len(record) {
+			field = value(record[i+1])
+		}
+		data, err := json.Marshal(field)
+		if err != nil {
+			data, _ = json.Marshal(fmt.Sprint(field))
+		}
+		line.Write(data)
+	}
+	line.WriteString("}\n")
+	return line.Bytes()
+}
+
+func encodeLogfmt(record []interface{}) []byte {
+	var line bytes.Buffer
+	for i := 0; i < len(record); i += 2 {
+		if i > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(logfmtKey(key(record[i])))
+		line.WriteByte('=')
+		var field interface{} = "!MISSING"
+		if i+1 < len(record) {
+			field = value(record[i+1])
+		}
+		line.WriteString(logfmtValue(field))
+	}
+	line.WriteByte('\n')
+	return line.Bytes()
+}
+
+func logfmtKey(k string) string {
+	result := []rune(k)
+	for i, r := range result {
+		if r <= ' ' || r == '=' || r == '"' {
+			result[i] = '_'
+		}
+	}
+	if len(result) == 0 {
+		return "_"
+	}
+	return string(result)
+}
+
+func logfmtValue(v interface{}) string {
+	var text string
+	switch typed := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		text = typed
+	default:
+		text = fmt.Sprint(typed)
+	}
+	if text == "" {
+		return `""`
+	}
+	for _, r := range text {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+			return strconv.Quote(text)
+		}
+	}
+	return text
+}
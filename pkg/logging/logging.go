@@ -0,0 +1,189 @@
+// Package logging writes leveled structured logs as JSON or logfmt.
+// Request id and fields of request (e.g. user id) are taken from context
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+func ParseLevel(text string) (Level, error) {
+	for level := LevelDebug; level <= LevelError; level++ {
+		if strings.EqualFold(text, level.String()) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", text)
+}
+
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatLogfmt Format = "logfmt"
+)
+
+func ParseFormat(text string) (Format, error) {
+	switch Format(strings.ToLower(text)) {
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatLogfmt:
+		return FormatLogfmt, nil
+	default:
+		return FormatLogfmt, fmt.Errorf("unknown log format %q", text)
+	}
+}
+
+// Logger writes one line per record. Fields are pairs of key and value
+type Logger struct {
+	mutex  *sync.Mutex
+	out    io.Writer
+	format Format
+	level  Level
+	fields []interface{}
+}
+
+func New(out io.Writer, format Format, level Level) *Logger {
+	return &Logger{mutex: &sync.Mutex{}, out: out, format: format, level: level}
+}
+
+// With returns logger which adds fields to every record
+func (l *Logger) With(fields ...interface{}) *Logger {
+	copied := *l
+	copied.fields = append(append(make([]interface{}, 0, len(l.fields)+len(fields)), l.fields...), fields...)
+	return &copied
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) Log(ctx context.Context, level Level, msg string, fields ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	record := make([]interface{}, 0, 8+len(l.fields)+len(fields))
+	record = append(record, "time", time.Now().UTC().Format(time.RFC3339Nano), "level", level.String(), "msg", msg)
+	if id := RequestID(ctx); id != "" {
+		record = append(record, "request_id", id)
+	}
+	record = append(record, requestFields(ctx)...)
+	record = append(record, l.fields...)
+	record = append(record, fields...)
+
+	var line []byte
+	if l.format == FormatJSON {
+		line = encodeJSON(record)
+	} else {
+		line = encodeLogfmt(record)
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = l.out.Write(line)
+}
+
+func (l *Logger) Debug(ctx context.Context, msg string, fields ...interface{}) {
+	l.Log(ctx, LevelDebug, msg, fields...)
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, fields ...interface{}) {
+	l.Log(ctx, LevelInfo, msg, fields...)
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, fields ...interface{}) {
+	l.Log(ctx, LevelWarn, msg, fields...)
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, fields ...interface{}) {
+	l.Log(ctx, LevelError, msg, fields...)
+}
+
+var std = New(os.Stderr, FormatLogfmt, LevelInfo)
+
+// SetDefault replaces logger used by package functions
+func SetDefault(logger *Logger) {
+	std = logger
+}
+
+func Default() *Logger {
+	return std
+}
+
+func Debug(ctx context.Context, msg string, fields ...interface{}) {
+	std.Log(ctx, LevelDebug, msg, fields...)
+}
+
+func Info(ctx context.Context, msg string, fields ...interface{}) {
+	std.Log(ctx, LevelInfo, msg, fields...)
+}
+
+func Warn(ctx context.Context, msg string, fields ...interface{}) {
+	std.Log(ctx, LevelWarn, msg, fields...)
+}
+
+func Error(ctx context.Context, msg string, fields ...interface{}) {
+	std.Log(ctx, LevelError, msg, fields...)
+}
+
+// Debugf and others are for messages which are text only, like most of handler errors
+
+func Debugf(ctx context.Context, format string, args ...interface{}) {
+	if std.Enabled(LevelDebug) {
+		std.Log(ctx, LevelDebug, fmt.Sprintf(format, args...))
+	}
+}
+
+func Infof(ctx context.Context, format string, args ...interface{}) {
+	std.Log(ctx, LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func Warnf(ctx context.Context, format string, args ...interface{}) {
+	std.Log(ctx, LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func Errorf(ctx context.Context, format string, args ...interface{}) {
+	std.Log(ctx, LevelError, fmt.Sprintf(format, args...))
+}
+
+// StdWriter is output for standard log package, so packages using it write records too.
+// Flags of standard logger must be 0, time is added by Logger
+func StdWriter(logger *Logger, level Level) io.Writer {
+	return stdWriter{logger: logger, level: level}
+}
+
+type stdWriter struct {
+	logger *Logger
+	level  Level
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	w.logger.Log(context.Background(), w.level, strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
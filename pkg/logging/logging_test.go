@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withoutTime drops time field which is first in every record
+func withoutTime(line string) string {
+	if strings.HasPrefix(line, "{") {
+		return "{" + line[strings.Index(line, `,"level"`)+1:]
+	}
+	return line[strings.Index(line, " level=")+1:]
+}
+
+func TestLog(t *testing.T) {
+	ctx := WithFields(WithRequestID(context.Background(), "req-1"))
+	AddField(ctx, "user_id", 7)
+	AddField(ctx, "user_id", 8)
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatJSON, `{"level":"warn","msg":"can't pay","request_id":"req-1","user_id":8,"component":"payments","error":"declined","took":"1.5s","note":"a \"b\"","odd":"!MISSING"}` + "\n"},
+		{FormatLogfmt, `level=warn msg="can't pay" request_id=req-1 user_id=8 component=payments error=declined took=1.5s note="a \"b\"" odd=!MISSING` + "\n"},
+	}
+	for _, test := range tests {
+		out := &bytes.Buffer{}
+		logger := New(out, test.format, LevelInfo).With("component", "payments")
+		logger.Warn(ctx, "can't pay", "error", errors.New("declined"), "took", 1500*time.Millisecond, "note", `a "b"`, "odd")
+		if got := withoutTime(out.String()); got != test.want {
+			t.Errorf("%s:\n got %s\nwant %s", test.format, got, test.want)
+		}
+	}
+}
+
+func TestLogTimeAndValidJSON(t *testing.T) {
+	out := &bytes.Buffer{}
+	New(out, FormatJSON, LevelDebug).Debug(context.Background(), "started", "line", "a\nb", "empty", "")
+	var record map[string]interface{}
+	err := json.Unmarshal(out.Bytes(), &record)
+	if err != nil {
+		t.Fatalf("record isn't json: %s", out)
+	}
+	moment, err := time.Parse(time.RFC3339Nano, record["time"].(string))
+	if err != nil || time.Since(moment) > time.Minute {
+		t.Errorf("time = %v", record["time"])
+	}
+	if _, ok := record["request_id"]; ok {
+		t.Errorf("record without request id has request_id: %s", out)
+	}
+	if record["line"] != "a\nb" {
+		t.Errorf("line = %q", record["line"])
+	}
+}
+
+func TestLevels(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := New(out, FormatLogfmt, LevelWarn)
+	logger.Debug(context.Background(), "debug")
+	logger.Info(context.Background(), "info")
+	logger.Warn(context.Background(), "warn")
+	logger.Error(context.Background(), "error")
+	if lines := strings.Count(out.String(), "\n"); lines != 2 || strings.Contains(out.String(), "msg=info") {
+		t.Errorf("records:\n%s", out)
+	}
+
+	for _, text := range []string{"debug", "INFO", "Warn", "error"} {
+		level, err := ParseLevel(text)
+		if err != nil || !strings.EqualFold(level.String(), text) {
+			t.Errorf("ParseLevel(%q) = %s, %v", text, level, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("ParseLevel(verbose) must fail")
+	}
+	if format, err := ParseFormat("JSON"); err != nil || format != FormatJSON {
+		t.Errorf("ParseFormat(JSON) = %s, %v", format, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("ParseFormat(xml) must fail")
+	}
+}
+
+func TestPackageFunctionsAndStdWriter(t *testing.T) {
+	previous := Default()
+	defer SetDefault(previous)
+	out := &bytes.Buffer{}
+	SetDefault(New(out, FormatLogfmt, LevelInfo))
+
+	ctx := WithRequestID(context.Background(), "req-2")
+	Errorf(ctx, "can't get card %d", 5)
+	Debugf(ctx, "hidden %d", 1)
+	std := log.New(StdWriter(Default(), LevelWarn), "", 0)
+	std.Println("from standard logger")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("records:\n%s", out)
+	}
+	if withoutTime(lines[0]) != `level=error msg="can't get card 5" request_id=req-2` {
+		t.Errorf("record = %s", lines[0])
+	}
+	if withoutTime(lines[1]) != `level=warn msg="from standard logger"` {
+		t.Errorf("record = %s", lines[1])
+	}
+}
+
+func TestFieldsWithoutPlace(t *testing.T) {
+	ctx := context.Background()
+	AddField(ctx, "user_id", 1)
+	if requestFields(ctx) != nil || RequestID(ctx) != "" || RequestID(nil) != "" {
+		t.Errorf("context without fields has fields")
+	}
+}
@@ -2,8 +2,9 @@ package jwt
 
 import (
 	"context"
+	"github.com/jafarsirojov/bank-front/pkg/core/confirm"
 	jwtcore "github.com/jafarsirojov/bank-front/pkg/jwt"
-	"log"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
 	"net/http"
 	"reflect"
 	"strings"
@@ -62,6 +63,20 @@ func JWT(source int, payloadType reflect.Type, secret jwtcore.Secret) func(next
 				return
 			}
 
+			// confirmation tokens must not be used as session
+			var claims struct {
+				Type string `json:"typ"`
+			}
+			err = jwtcore.Decode(token, &claims)
+			if err != nil {
+				http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			if claims.Type == confirm.TokenType {
+				http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
 			payload := reflect.New(payloadType).Interface()
 
 			err = jwtcore.Decode(token, payload)
@@ -81,7 +96,10 @@ func JWT(source int, payloadType reflect.Type, secret jwtcore.Secret) func(next
 				return
 			}
 
-			log.Print(payload)
+			// user is written to access log of request
+			if user, ok := payload.(interface{ UserID() int }); ok {
+				logging.AddField(request.Context(), "user_id", user.UserID())
+			}
 
 			ctx := context.WithValue(request.Context(), payloadContextKey, payload)
 			next(writer, request.WithContext(ctx))
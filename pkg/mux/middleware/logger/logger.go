@@ -1,22 +1,48 @@
 package logger
 
 import (
-	"log"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"github.com/jafarsirojov/bank-front/pkg/mux/response"
 	"net/http"
+	"time"
 )
 
+// Logger writes access log record after request is handled. User id and other
+// fields added by inner middlewares with logging.AddField are written too
 func Logger(prefix string) func(
 	next http.HandlerFunc,
 ) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(writer http.ResponseWriter, request *http.Request) {
-			log.Printf(
-				"%s Method: %s, path: %s",
-				prefix,
-				request.Method,
-				request.URL.Path,
-			)
-			next(writer, request)
+			start := time.Now()
+			ctx := logging.WithFields(request.Context())
+			recorder := response.NewRecorder(writer)
+			next(recorder, request.WithContext(ctx))
+
+			level := logging.LevelInfo
+			switch {
+			case recorder.Status() >= http.StatusInternalServerError:
+				level = logging.LevelError
+			case recorder.Status() >= http.StatusBadRequest:
+				level = logging.LevelWarn
+			}
+			fields := []interface{}{
+				"component", prefix,
+				"method", request.Method,
+				"path", request.URL.Path,
+				"status", recorder.Status(),
+				"bytes", recorder.Bytes(),
+				"ttfb_ms", milliseconds(recorder.TTFB()),
+				"duration_ms", milliseconds(time.Since(start)),
+			}
+			if recorder.Hijacked() {
+				fields = append(fields, "hijacked", true)
+			}
+			logging.Default().Log(ctx, level, "request", fields...)
 		}
 	}
 }
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
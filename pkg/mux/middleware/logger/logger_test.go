@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"github.com/jafarsirojov/bank-front/pkg/mux/middleware/requestid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	previous := logging.Default()
+	defer logging.SetDefault(previous)
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		level  string
+	}{
+		{"ok", http.StatusOK, "hello", "info"},
+		{"not found", http.StatusNotFound, "", "warn"},
+		{"failed", http.StatusBadGateway, "upstream", "error"},
+	}
+	for _, test := range tests {
+		out := &bytes.Buffer{}
+		logging.SetDefault(logging.New(out, logging.FormatJSON, logging.LevelInfo))
+
+		handler := requestid.RequestID()(Logger("HTTP")(func(writer http.ResponseWriter, request *http.Request) {
+			// e.g. jwt middleware adds user of request
+			logging.AddField(request.Context(), "user_id", 7)
+			if test.status != http.StatusOK {
+				writer.WriteHeader(test.status)
+			}
+			_, _ = writer.Write([]byte(test.body))
+		}))
+		request := httptest.NewRequest(http.MethodPost, "/cards?id=1", nil)
+		request.Header.Set(logging.RequestIDHeader, "req-1")
+		handler(httptest.NewRecorder(), request)
+
+		var record map[string]interface{}
+		err := json.Unmarshal(out.Bytes(), &record)
+		if err != nil {
+			t.Fatalf("%s: record isn't json: %s", test.name, out)
+		}
+		want := map[string]interface{}{
+			"level":      test.level,
+			"msg":        "request",
+			"request_id": "req-1",
+			"user_id":    float64(7),
+			"component":  "HTTP",
+			"method":     "POST",
+			"path":       "/cards",
+			"status":     float64(test.status),
+			"bytes":      float64(len(test.body)),
+		}
+		for key, value := range want {
+			if record[key] != value {
+				t.Errorf("%s: %s = %v, want %v", test.name, key, record[key], value)
+			}
+		}
+		for _, key := range []string{"ttfb_ms", "duration_ms"} {
+			if _, ok := record[key].(float64); !ok {
+				t.Errorf("%s: no %s in %s", test.name, key, out)
+			}
+		}
+	}
+}
@@ -1,7 +1,8 @@
 package recoverer
 
 import (
-	"log"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
 	"net/http"
 	"runtime/debug"
 )
@@ -15,7 +16,7 @@ func Recoverer() func(
 			// handle panic
 			defer func() {
 				if err := recover(); err != nil {
-					log.Printf("%s", debug.Stack())
+					logging.Error(request.Context(), "panic", "error", fmt.Sprint(err), "stack", string(debug.Stack()))
 					http.Error(
 						writer,
 						http.StatusText(http.StatusInternalServerError),
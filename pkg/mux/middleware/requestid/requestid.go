@@ -0,0 +1,36 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"net/http"
+	"regexp"
+)
+
+// id from client or proxy is kept if it is safe to write to logs and headers
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID takes X-Request-ID of request or makes new one, puts it to context and response
+func RequestID() func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(writer http.ResponseWriter, request *http.Request) {
+			id := request.Header.Get(logging.RequestIDHeader)
+			if !validID.MatchString(id) {
+				id = newID()
+			}
+			writer.Header().Set(logging.RequestIDHeader, id)
+			next(writer, request.WithContext(logging.WithRequestID(request.Context(), id)))
+		}
+	}
+}
+
+func newID() string {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		// reading random never fails on supported systems, but request must go on
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
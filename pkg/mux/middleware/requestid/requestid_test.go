@@ -0,0 +1,75 @@
+package requestid
+
+import (
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{"from proxy", "5f2b0c1e-9a1d-4d1e-8d1a-000000000001", true},
+		{"missing", "", false},
+		{"unsafe", "id\" injected=1", false},
+		{"too long", strings.Repeat("a", 129), false},
+	}
+	for _, test := range tests {
+		var inContext string
+		handler := RequestID()(func(writer http.ResponseWriter, request *http.Request) {
+			inContext = logging.RequestID(request.Context())
+		})
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.incoming != "" {
+			request.Header.Set(logging.RequestIDHeader, test.incoming)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+
+		sent := recorder.Header().Get(logging.RequestIDHeader)
+		if sent != inContext {
+			t.Errorf("%s: response has %q, context has %q", test.name, sent, inContext)
+		}
+		if test.kept && sent != test.incoming {
+			t.Errorf("%s: id = %q, want %q", test.name, sent, test.incoming)
+		}
+		if !test.kept && !generated.MatchString(sent) {
+			t.Errorf("%s: id = %q, want new id", test.name, sent)
+		}
+	}
+}
+
+// id of incoming request reaches upstream service through client with logging.Transport
+func TestRequestIDForwardedUpstream(t *testing.T) {
+	var received string
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received = request.Header.Get(logging.RequestIDHeader)
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: logging.Transport{Base: upstream.Client().Transport}}
+
+	handler := RequestID()(func(writer http.ResponseWriter, request *http.Request) {
+		upstreamRequest, err := http.NewRequestWithContext(request.Context(), http.MethodGet, upstream.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := client.Do(upstreamRequest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	})
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(logging.RequestIDHeader, "req-42")
+	handler(httptest.NewRecorder(), request)
+	if received != "req-42" {
+		t.Errorf("upstream got request id %q, want req-42", received)
+	}
+}
@@ -0,0 +1,103 @@
+package response
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+var ErrHijackNotSupported = errors.New("response writer doesn't support hijacking")
+
+// Recorder wraps http.ResponseWriter and remembers status, size and time to first byte.
+// Flush and Hijack are passed on, so server-sent events and websockets keep working
+type Recorder struct {
+	http.ResponseWriter
+	start    time.Time
+	status   int
+	bytes    int64
+	ttfb     time.Duration
+	hijacked bool
+}
+
+func NewRecorder(writer http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: writer, start: time.Now()}
+}
+
+func (r *Recorder) WriteHeader(status int) {
+	// informational headers (103 Early Hints) can go before final one
+	if r.status == 0 && (status >= http.StatusOK || status == http.StatusSwitchingProtocols) {
+		r.written(status)
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *Recorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.written(http.StatusOK)
+	}
+	n, err := r.ResponseWriter.Write(data)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends headers with 200 if handler didn't write anything yet
+func (r *Recorder) Flush() {
+	flusher, ok := r.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+	if r.status == 0 {
+		r.written(http.StatusOK)
+	}
+	flusher.Flush()
+}
+
+// Hijack gives connection to handler, status is 101 then and bytes aren't counted anymore
+func (r *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijackNotSupported
+	}
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	r.hijacked = true
+	if r.status == 0 {
+		r.written(http.StatusSwitchingProtocols)
+	}
+	return conn, buffer, nil
+}
+
+// Unwrap lets http.ResponseController reach original writer
+func (r *Recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *Recorder) written(status int) {
+	r.status = status
+	r.ttfb = time.Since(r.start)
+}
+
+// Status is 200 if handler wrote nothing, net/http sends it then
+func (r *Recorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func (r *Recorder) Bytes() int64 {
+	return r.bytes
+}
+
+// TTFB is time from NewRecorder to headers being written, zero if handler wrote nothing
+func (r *Recorder) TTFB() time.Duration {
+	return r.ttfb
+}
+
+func (r *Recorder) Hijacked() bool {
+	return r.hijacked
+}
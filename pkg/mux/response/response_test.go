@@ -0,0 +1,116 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		handle  func(writer http.ResponseWriter)
+		status  int
+		bytes   int64
+		written bool
+	}{
+		{"nothing written", func(writer http.ResponseWriter) {}, http.StatusOK, 0, false},
+		{"body only", func(writer http.ResponseWriter) {
+			_, _ = writer.Write([]byte("hello"))
+		}, http.StatusOK, 5, true},
+		{"status and body", func(writer http.ResponseWriter) {
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = writer.Write([]byte("none"))
+		}, http.StatusNotFound, 4, true},
+		{"early hints", func(writer http.ResponseWriter) {
+			writer.WriteHeader(http.StatusEarlyHints)
+			writer.WriteHeader(http.StatusCreated)
+		}, http.StatusCreated, 0, true},
+		{"second status ignored", func(writer http.ResponseWriter) {
+			writer.WriteHeader(http.StatusBadGateway)
+			writer.WriteHeader(http.StatusOK)
+		}, http.StatusBadGateway, 0, true},
+	}
+	for _, test := range tests {
+		recorder := NewRecorder(httptest.NewRecorder())
+		test.handle(recorder)
+		if recorder.Status() != test.status {
+			t.Errorf("%s: status = %d, want %d", test.name, recorder.Status(), test.status)
+		}
+		if recorder.Bytes() != test.bytes {
+			t.Errorf("%s: bytes = %d, want %d", test.name, recorder.Bytes(), test.bytes)
+		}
+		if (recorder.status != 0) != test.written {
+			t.Errorf("%s: headers written = %v, want %v", test.name, recorder.status != 0, test.written)
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	original := httptest.NewRecorder()
+	recorder := NewRecorder(original)
+	recorder.Flush()
+	if !original.Flushed {
+		t.Errorf("Flush() isn't passed to writer")
+	}
+	if recorder.status != http.StatusOK {
+		t.Errorf("status after Flush() = %d, want 200", recorder.status)
+	}
+
+	// writer without Flush is left alone
+	recorder = NewRecorder(struct{ http.ResponseWriter }{httptest.NewRecorder()})
+	recorder.Flush()
+	if recorder.status != 0 {
+		t.Errorf("status after Flush() without flusher = %d, want none", recorder.status)
+	}
+}
+
+func TestHijackNotSupported(t *testing.T) {
+	recorder := NewRecorder(httptest.NewRecorder())
+	_, _, err := recorder.Hijack()
+	if !errors.Is(err, ErrHijackNotSupported) {
+		t.Errorf("Hijack() error = %v, want ErrHijackNotSupported", err)
+	}
+	if recorder.Hijacked() {
+		t.Errorf("recorder is hijacked after failed Hijack()")
+	}
+}
+
+func TestHijack(t *testing.T) {
+	result := make(chan *Recorder, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		recorder := NewRecorder(writer)
+		conn, buffer, err := recorder.Hijack()
+		if err != nil {
+			t.Error(err)
+			result <- recorder
+			return
+		}
+		_, _ = buffer.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		_ = buffer.Flush()
+		conn.Close()
+		result <- recorder
+	}))
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("client got %d, want 101", response.StatusCode)
+	}
+	recorder := <-result
+	if !recorder.Hijacked() || recorder.Status() != http.StatusSwitchingProtocols {
+		t.Errorf("hijacked = %v, status = %d, want true, 101", recorder.Hijacked(), recorder.Status())
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	original := httptest.NewRecorder()
+	if NewRecorder(original).Unwrap() != original {
+		t.Errorf("Unwrap() doesn't return original writer")
+	}
+}
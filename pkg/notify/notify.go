@@ -0,0 +1,46 @@
+// Package notify sends alerts outside of app: email, sms or file for development.
+// Messages are put to Queue, it retries them when provider is unavailable
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is one alert, To is email or phone number depending on sender
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+var ErrNoRecipient = errors.New("message has no recipient")
+
+// permanent errors are not retried, e.g. provider rejected phone number
+type permanent struct {
+	err error
+}
+
+func (p permanent) Error() string {
+	return p.err.Error()
+}
+
+func (p permanent) Unwrap() error {
+	return p.err
+}
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanent{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var target permanent
+	return errors.As(err, &target)
+}
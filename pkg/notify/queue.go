@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"github.com/jafarsirojov/bank-front/pkg/logging"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAttempts = 8
+	defaultBackoff  = 10 * time.Second
+	maxBackoff      = time.Hour
+	sendTimeout     = 30 * time.Second
+	idleWait        = time.Minute
+)
+
+// Job is message waiting for channel, it is kept on disk so restart doesn't lose alerts
+type Job struct {
+	ID        int64     `json:"id"`
+	Channel   string    `json:"channel"`
+	Message   Message   `json:"message"`
+	Attempts  int       `json:"attempts"`
+	NextAt    time.Time `json:"next_at"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Queue sends messages by senders of channels and retries failed ones with growing delay
+type Queue struct {
+	mutex    sync.Mutex
+	file     *storage.File
+	data     queueData
+	senders  map[string]Sender
+	attempts int
+	backoff  time.Duration
+	wake     chan struct{}
+}
+
+type queueData struct {
+	NextID int64 `json:"next_id"`
+	Jobs   []Job `json:"jobs"`
+}
+
+func NewQueue(file *storage.File, senders map[string]Sender) (*Queue, error) {
+	queue := &Queue{
+		file:     file,
+		senders:  senders,
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+		wake:     make(chan struct{}, 1),
+	}
+	err := file.Load(&queue.data)
+	if err != nil {
+		return nil, fmt.Errorf("can't load notify queue: %w", err)
+	}
+	return queue, nil
+}
+
+// Channels returns names of channels which have sender
+func (q *Queue) Channels() []string {
+	names := make([]string, 0, len(q.senders))
+	for name := range q.senders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (q *Queue) Enqueue(channel string, message Message) error {
+	if _, ok := q.senders[channel]; !ok {
+		return fmt.Errorf("no sender for channel %s", channel)
+	}
+	if message.To == "" {
+		return ErrNoRecipient
+	}
+
+	q.mutex.Lock()
+	data := q.copyData()
+	data.NextID++
+	data.Jobs = append(data.Jobs, Job{
+		ID:      data.NextID,
+		Channel: channel,
+		Message: message,
+		NextAt:  time.Now(),
+	})
+	err := q.save(data)
+	q.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run sends jobs until ctx is done
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		q.Process(ctx, time.Now())
+		timer := time.NewTimer(q.wait(time.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-q.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Process sends jobs which are due at now
+func (q *Queue) Process(ctx context.Context, now time.Time) {
+	q.mutex.Lock()
+	due := make([]Job, 0)
+	for _, job := range q.data.Jobs {
+		if !job.NextAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	q.mutex.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	// senders are slow, so lock isn't held while sending and new jobs may be added
+	results := make(map[int64]error, len(due))
+	for _, job := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		sender, ok := q.senders[job.Channel]
+		if !ok {
+			// channel was turned off after job was saved
+			results[job.ID] = Permanent(fmt.Errorf("no sender for channel %s", job.Channel))
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		results[job.ID] = sender.Send(sendCtx, job.Message)
+		cancel()
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	jobs := make([]Job, 0, len(q.data.Jobs))
+	for _, job := range q.data.Jobs {
+		err, sent := results[job.ID]
+		switch {
+		case !sent:
+			jobs = append(jobs, job)
+		case err == nil:
+			logging.Infof(ctx, "notification %d sent by %s", job.ID, job.Channel)
+		default:
+			job.Attempts++
+			job.LastError = err.Error()
+			if IsPermanent(err) || job.Attempts >= q.attempts {
+				logging.Errorf(ctx, "notification %d by %s dropped after %d attempts: %v", job.ID, job.Channel, job.Attempts, err)
+				continue
+			}
+			job.NextAt = now.Add(q.delay(job.Attempts))
+			logging.Warnf(ctx, "notification %d by %s failed, next attempt at %s: %v", job.ID, job.Channel, job.NextAt.Format(time.RFC3339), err)
+			jobs = append(jobs, job)
+		}
+	}
+	data := queueData{NextID: q.data.NextID, Jobs: jobs}
+	err := q.save(data)
+	if err != nil {
+		logging.Errorf(ctx, "can't save outbox: %v", err)
+		// messages are sent already, memory must not send them again
+		q.data = data
+	}
+}
+
+// delay doubles after each attempt: 10s, 20s, 40s... up to an hour
+func (q *Queue) delay(attempts int) time.Duration {
+	delay := q.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+func (q *Queue) wait(now time.Time) time.Duration {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	wait := idleWait
+	for _, job := range q.data.Jobs {
+		if until := job.NextAt.Sub(now); until < wait {
+			wait = until
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// copyData returns data which can be changed without touching queue
+func (q *Queue) copyData() queueData {
+	return queueData{NextID: q.data.NextID, Jobs: append([]Job(nil), q.data.Jobs...)}
+}
+
+// save writes data and only then swaps it in, so memory doesn't differ from file on error
+func (q *Queue) save(data queueData) error {
+	err := q.file.Save(data)
+	if err != nil {
+		return fmt.Errorf("can't save notify queue: %w", err)
+	}
+	q.data = data
+	return nil
+}
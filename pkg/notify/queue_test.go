@@ -0,0 +1,193 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"github.com/jafarsirojov/bank-front/pkg/core/storage"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeSender fails while errs has errors and keeps sent messages
+type fakeSender struct {
+	sent []Message
+	errs []error
+}
+
+func (s *fakeSender) Send(ctx context.Context, message Message) error {
+	if len(s.errs) != 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return err
+	}
+	s.sent = append(s.sent, message)
+	return nil
+}
+
+func newTestQueue(t *testing.T, sender Sender) *Queue {
+	queue, err := NewQueue(storage.NewFile(""), map[string]Sender{"sms": sender})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queue
+}
+
+func TestDelay(t *testing.T) {
+	queue := newTestQueue(t, &fakeSender{})
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+	for _, test := range tests {
+		got := queue.delay(test.attempts)
+		if got != test.want {
+			t.Errorf("delay(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	queue := newTestQueue(t, &fakeSender{})
+	err := queue.Enqueue("email", Message{To: "user@example.com"})
+	if err == nil {
+		t.Error("Enqueue() to channel without sender must fail")
+	}
+	err = queue.Enqueue("sms", Message{})
+	if !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("Enqueue() error = %v, want ErrNoRecipient", err)
+	}
+	err = queue.Enqueue("sms", Message{To: "+992931234567", Body: "code"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.data.Jobs) != 1 || queue.data.Jobs[0].ID != 1 {
+		t.Errorf("jobs = %+v", queue.data.Jobs)
+	}
+	if queue.Channels()[0] != "sms" {
+		t.Errorf("Channels() = %v", queue.Channels())
+	}
+}
+
+func TestProcessRetriesWithBackoff(t *testing.T) {
+	sender := &fakeSender{errs: []error{errors.New("timeout"), errors.New("503")}}
+	queue := newTestQueue(t, sender)
+	err := queue.Enqueue("sms", Message{To: "+992931234567", Body: "code"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Add(time.Second)
+	queue.Process(context.Background(), now)
+	job := queue.data.Jobs[0]
+	if job.Attempts != 1 || job.LastError != "timeout" || !job.NextAt.Equal(now.Add(10*time.Second)) {
+		t.Fatalf("after first failure job = %+v", job)
+	}
+	if wait := queue.wait(now); wait != 10*time.Second {
+		t.Errorf("wait() = %s, want 10s", wait)
+	}
+
+	// not due yet
+	queue.Process(context.Background(), now.Add(5*time.Second))
+	if queue.data.Jobs[0].Attempts != 1 {
+		t.Fatalf("job sent before next attempt")
+	}
+
+	now = job.NextAt
+	queue.Process(context.Background(), now)
+	job = queue.data.Jobs[0]
+	if job.Attempts != 2 || !job.NextAt.Equal(now.Add(20*time.Second)) {
+		t.Fatalf("after second failure job = %+v", job)
+	}
+
+	queue.Process(context.Background(), job.NextAt)
+	if len(queue.data.Jobs) != 0 || len(sender.sent) != 1 || sender.sent[0].Body != "code" {
+		t.Errorf("jobs = %+v, sent = %+v", queue.data.Jobs, sender.sent)
+	}
+}
+
+func TestProcessDropsJobs(t *testing.T) {
+	tests := []struct {
+		name string
+		errs []error
+		runs int
+	}{
+		{"permanent error", []error{Permanent(errors.New("bad number"))}, 1},
+		{"attempts are over", []error{errors.New("1"), errors.New("2"), errors.New("3")}, 3},
+	}
+	for _, test := range tests {
+		sender := &fakeSender{errs: test.errs}
+		queue := newTestQueue(t, sender)
+		queue.attempts = 3
+		err := queue.Enqueue("sms", Message{To: "+992931234567"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now().Add(time.Second)
+		for i := 0; i < test.runs; i++ {
+			if len(queue.data.Jobs) == 0 {
+				t.Fatalf("%s: job dropped after %d runs", test.name, i)
+			}
+			now = queue.data.Jobs[0].NextAt
+			queue.Process(context.Background(), now)
+		}
+		if len(queue.data.Jobs) != 0 || len(sender.sent) != 0 {
+			t.Errorf("%s: jobs = %+v, sent = %+v", test.name, queue.data.Jobs, sender.sent)
+		}
+	}
+}
+
+func TestProcessDropsJobOfRemovedChannel(t *testing.T) {
+	queue := newTestQueue(t, &fakeSender{})
+	queue.data.Jobs = []Job{{ID: 1, Channel: "email", Message: Message{To: "user@example.com"}}}
+	queue.Process(context.Background(), time.Now())
+	if len(queue.data.Jobs) != 0 {
+		t.Errorf("jobs = %+v", queue.data.Jobs)
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	base := errors.New("rejected")
+	if Permanent(nil) != nil || IsPermanent(base) || !IsPermanent(Permanent(base)) || !errors.Is(Permanent(base), base) {
+		t.Errorf("permanent errors aren't recognized")
+	}
+}
+
+func TestFailedEnqueueKeepsMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	queue, err := NewQueue(storage.NewFile(filepath.Join(dir, "data", "outbox.json")), map[string]Sender{"sms": &fakeSender{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// file in place of directory makes every save fail
+	err = ioutil.WriteFile(filepath.Join(dir, "data"), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = queue.Enqueue("sms", Message{To: "+992931234567"})
+	if err == nil {
+		t.Fatal("Enqueue() must fail")
+	}
+	if len(queue.data.Jobs) != 0 || queue.data.NextID != 0 {
+		t.Errorf("queue changed after failed save: %+v", queue.data)
+	}
+	select {
+	case <-queue.wake:
+		t.Errorf("queue is woken for unsaved job")
+	default:
+	}
+}
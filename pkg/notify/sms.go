@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// SMS sends text through HTTP provider: POST {"from", "to", "text"} with bearer token
+type SMS struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+func NewSMS(url string, token string, from string) *SMS {
+	return &SMS{url: url, token: token, from: from, client: http.DefaultClient}
+}
+
+type smsRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+func (s *SMS) Send(ctx context.Context, message Message) error {
+	if message.To == "" {
+		return Permanent(ErrNoRecipient)
+	}
+	data, err := json.Marshal(smsRequest{From: s.from, To: message.To, Text: message.Body})
+	if err != nil {
+		return Permanent(fmt.Errorf("can't encode sms: %w", err))
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return Permanent(fmt.Errorf("can't create request: %w", err))
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.token))
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("can't send sms: %w", err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4<<10))
+
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		return nil
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500:
+		return fmt.Errorf("sms provider responded %d", response.StatusCode)
+	default:
+		// message itself is bad, sending it again gives same answer
+		return Permanent(fmt.Errorf("sms provider rejected message to %s: %d", message.To, response.StatusCode))
+	}
+}
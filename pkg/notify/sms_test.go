@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSMSSend(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		failed    bool
+		permanent bool
+	}{
+		{"sent", http.StatusOK, false, false},
+		{"accepted", http.StatusAccepted, false, false},
+		{"rate limit", http.StatusTooManyRequests, true, false},
+		{"provider down", http.StatusServiceUnavailable, true, false},
+		{"bad number", http.StatusBadRequest, true, true},
+		{"bad token", http.StatusUnauthorized, true, true},
+	}
+	for _, test := range tests {
+		var sent smsRequest
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method != http.MethodPost || request.Header.Get("Authorization") != "Bearer token" || request.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("%s: request %s %v", test.name, request.Method, request.Header)
+			}
+			_ = json.NewDecoder(request.Body).Decode(&sent)
+			writer.WriteHeader(test.status)
+		}))
+
+		err := NewSMS(server.URL, "token", "JBank").Send(context.Background(), Message{To: "+992931234567", Subject: "ignored", Body: "code 1234"})
+		server.Close()
+		if (err != nil) != test.failed || IsPermanent(err) != test.permanent {
+			t.Errorf("%s: error = %v, want failed %v, permanent %v", test.name, err, test.failed, test.permanent)
+		}
+		if sent != (smsRequest{From: "JBank", To: "+992931234567", Text: "code 1234"}) {
+			t.Errorf("%s: sent %+v", test.name, sent)
+		}
+	}
+}
+
+func TestSMSSendErrors(t *testing.T) {
+	err := NewSMS("http://127.0.0.1:1", "", "JBank").Send(context.Background(), Message{})
+	if !errors.Is(err, ErrNoRecipient) || !IsPermanent(err) {
+		t.Errorf("error = %v, want permanent ErrNoRecipient", err)
+	}
+
+	// provider isn't reachable, message is retried
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	url := server.URL
+	server.Close()
+	err = NewSMS(url, "", "JBank").Send(context.Background(), Message{To: "+992931234567"})
+	if err == nil || IsPermanent(err) {
+		t.Errorf("error = %v, want temporary error", err)
+	}
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTP sends email, in development server may be local stand-in like MailHog
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP makes sender without authentication if username is empty
+func NewSMTP(addr string, from string, username string, password string) *SMTP {
+	sender := &SMTP{addr: addr, from: from}
+	if username != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		sender.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return sender
+}
+
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	if message.To == "" {
+		return Permanent(ErrNoRecipient)
+	}
+	data, err := s.compose(message)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	// smtp package has no context, so deadline only stops waiting for result
+	result := make(chan error, 1)
+	go func() {
+		result <- smtp.SendMail(s.addr, s.auth, s.from, []string{message.To}, data)
+	}()
+	select {
+	case err := <-result:
+		if err != nil {
+			return fmt.Errorf("can't send email to %s: %w", message.To, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *SMTP) compose(message Message) ([]byte, error) {
+	var data bytes.Buffer
+	fmt.Fprintf(&data, "From: %s\r\n", s.from)
+	fmt.Fprintf(&data, "To: %s\r\n", message.To)
+	fmt.Fprintf(&data, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&data, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	data.WriteString("MIME-Version: 1.0\r\n")
+	data.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	data.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	data.WriteString("\r\n")
+	body := quotedprintable.NewWriter(&data)
+	_, err := io.WriteString(body, message.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't write email: %w", err)
+	}
+	err = body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("can't write email: %w", err)
+	}
+	data.WriteString("\r\n")
+	return data.Bytes(), nil
+}
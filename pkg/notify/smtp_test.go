@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime/quotedprintable"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts one message and sends its data to messages, code answers RCPT TO
+func fakeSMTP(t *testing.T, code int) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := make(chan string, 1)
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		_ = text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250 localhost")
+			case "RCPT":
+				_ = text.PrintfLine("%d recipient", code)
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, _ := text.ReadDotLines()
+				messages <- strings.Join(data, "\r\n")
+				_ = text.PrintfLine("250 queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				return
+			default:
+				_ = text.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return listener.Addr().String(), messages
+}
+
+func TestSMTPSend(t *testing.T) {
+	addr, messages := fakeSMTP(t, 250)
+	sender := NewSMTP(addr, "bank@example.com", "", "")
+	err := sender.Send(context.Background(), Message{To: "user@example.com", Subject: "Перевод выполнен", Body: "С карты **** 1111 переведено 10,00 TJS."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := <-messages
+	header, body := data, ""
+	if i := strings.Index(data, "\r\n\r\n"); i != -1 {
+		header, body = data[:i], data[i+4:]
+	}
+	for _, line := range []string{
+		"From: bank@example.com",
+		"To: user@example.com",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+	} {
+		if !strings.Contains(header, line) {
+			t.Errorf("header has no %q:\n%s", line, header)
+		}
+	}
+	decoded, err := ioutil.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(decoded)) != "С карты **** 1111 переведено 10,00 TJS." {
+		t.Errorf("body = %q", decoded)
+	}
+}
+
+func TestSMTPSendErrors(t *testing.T) {
+	err := NewSMTP("127.0.0.1:1", "bank@example.com", "", "").Send(context.Background(), Message{})
+	if !errors.Is(err, ErrNoRecipient) || !IsPermanent(err) {
+		t.Errorf("error = %v, want permanent ErrNoRecipient", err)
+	}
+
+	addr, _ := fakeSMTP(t, 550)
+	err = NewSMTP(addr, "bank@example.com", "", "").Send(context.Background(), Message{To: "user@example.com"})
+	if err == nil || IsPermanent(err) {
+		t.Errorf("error = %v, want error which is retried", err)
+	}
+
+	// server accepts connection and never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			<-done
+			conn.Close()
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = NewSMTP(listener.Addr().String(), "bank@example.com", "", "").Send(ctx, Message{To: "user@example.com"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var ErrNoTemplate = errors.New("no template")
+
+// Templates render messages per event type. Files define "<event>.subject" and body for
+// each channel, e.g. "<event>.email" and "<event>.sms". Event without body for channel isn't sent to it
+type Templates struct {
+	tpl *template.Template
+}
+
+// LoadTemplates parses *.tmpl files in dir
+func LoadTemplates(dir string) (*Templates, error) {
+	tpl, err := template.New("notify").Option("missingkey=error").ParseGlob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse notification templates: %w", err)
+	}
+	return &Templates{tpl: tpl}, nil
+}
+
+func (t *Templates) Has(event string, channel string) bool {
+	return t.tpl.Lookup(event+"."+channel) != nil
+}
+
+// Render makes message without recipient
+func (t *Templates) Render(event string, channel string, data interface{}) (Message, error) {
+	if !t.Has(event, channel) {
+		return Message{}, fmt.Errorf("%w for %s by %s", ErrNoTemplate, event, channel)
+	}
+	body, err := t.execute(event+"."+channel, data)
+	if err != nil {
+		return Message{}, err
+	}
+	message := Message{Body: body}
+	if t.tpl.Lookup(event+".subject") != nil {
+		message.Subject, err = t.execute(event+".subject", data)
+		if err != nil {
+			return Message{}, err
+		}
+	}
+	return message, nil
+}
+
+func (t *Templates) execute(name string, data interface{}) (string, error) {
+	var result bytes.Buffer
+	err := t.tpl.ExecuteTemplate(&result, name, data)
+	if err != nil {
+		return "", fmt.Errorf("can't execute template %s: %w", name, err)
+	}
+	return strings.TrimSpace(result.String()), nil
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTemplates(t *testing.T, text string) *Templates {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "test.tmpl"), []byte(text), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return templates
+}
+
+func TestRender(t *testing.T) {
+	templates := writeTemplates(t, `
+{{ define "sent.subject" }}Перевод {{ .amount }}{{ end }}
+{{ define "sent.email" }}
+Переведено {{ .amount }}.
+{{ end }}
+{{ define "sent.sms" }}Перевод {{ .amount }} & {{ .card }}{{ end }}
+{{ define "login.sms" }}Вход{{ end }}
+`)
+	tests := []struct {
+		name    string
+		event   string
+		channel string
+		data    map[string]string
+		message Message
+		err     bool
+	}{
+		{"email", "sent", "email", map[string]string{"amount": "10 TJS", "card": "**** 1111"}, Message{Subject: "Перевод 10 TJS", Body: "Переведено 10 TJS."}, false},
+		// text templates, nothing is escaped
+		{"sms", "sent", "sms", map[string]string{"amount": "10 TJS", "card": "<1111>"}, Message{Subject: "Перевод 10 TJS", Body: "Перевод 10 TJS & <1111>"}, false},
+		{"without subject", "login", "sms", nil, Message{Body: "Вход"}, false},
+		{"missing key", "sent", "sms", map[string]string{"amount": "10 TJS"}, Message{}, true},
+	}
+	for _, test := range tests {
+		message, err := templates.Render(test.event, test.channel, test.data)
+		if (err != nil) != test.err || message != test.message {
+			t.Errorf("%s: Render() = %+v, %v, want %+v", test.name, message, err, test.message)
+		}
+	}
+
+	if templates.Has("login", "email") {
+		t.Errorf("Has() is true for event without body of channel")
+	}
+	_, err := templates.Render("login", "email", nil)
+	if !errors.Is(err, ErrNoTemplate) {
+		t.Errorf("Render() error = %v, want ErrNoTemplate", err)
+	}
+}
+
+func TestConfigTemplatesParse(t *testing.T) {
+	templates, err := LoadTemplates(filepath.Join("..", "..", "configs", "notify"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !templates.Has("money_sent", "email") || !templates.Has("money_sent", "sms") {
+		t.Errorf("money_sent templates are missing")
+	}
+}
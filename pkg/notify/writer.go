@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Writer prints messages instead of sending them, for local development
+type Writer struct {
+	mutex sync.Mutex
+	name  string
+	open  func() (io.WriteCloser, error)
+}
+
+// NewConsole writes messages of channel name to stdout
+func NewConsole(name string) *Writer {
+	return &Writer{name: name, open: func() (io.WriteCloser, error) {
+		return nopCloser{os.Stdout}, nil
+	}}
+}
+
+// NewFile appends messages of channel name to file at path
+func NewFile(name string, path string) *Writer {
+	return &Writer{name: name, open: func() (io.WriteCloser, error) {
+		return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	}}
+}
+
+func (w *Writer) Send(ctx context.Context, message Message) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	out, err := w.open()
+	if err != nil {
+		return fmt.Errorf("can't open output of %s: %w", w.name, err)
+	}
+	_, err = fmt.Fprintf(out, "==== %s %s to %s\nSubject: %s\n\n%s\n\n", w.name, time.Now().Format(time.RFC3339), message.To, message.Subject, message.Body)
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("can't write message of %s: %w", w.name, err)
+	}
+	return nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,25 @@
+// bell in navbar shows how many notifications aren't read, count is refreshed while page is open
+(function () {
+    var bells = document.querySelectorAll('[data-bell-count]');
+    if (!bells.length || !window.fetch) {
+        return;
+    }
+    var refresh = function () {
+        fetch('/notifications/unread', {credentials: 'same-origin', cache: 'no-store'})
+            .then(function (response) {
+                return response.ok ? response.json() : null;
+            })
+            .then(function (data) {
+                if (!data) {
+                    return;
+                }
+                for (var i = 0; i < bells.length; i++) {
+                    bells[i].textContent = data.unread > 99 ? '99+' : (data.unread || '');
+                }
+            })
+            .catch(function () {
+            });
+    };
+    refresh();
+    setInterval(refresh, 30000);
+})();